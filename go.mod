@@ -2,4 +2,4 @@ module github.com/tangledbytes/godc
 
 go 1.20
 
-require github.com/cespare/xxhash v1.1.0 // indirect
+require github.com/cespare/xxhash v1.1.0
@@ -0,0 +1,90 @@
+package bench
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/pkg/barrier"
+)
+
+func barriers() map[string]func(n int) func(id int) barrier.Barrier {
+	return map[string]func(n int) func(id int) barrier.Barrier{
+		"sense": func(n int) func(id int) barrier.Barrier {
+			b := barrier.NewSense(n)
+			return func(int) barrier.Barrier { return b }
+		},
+		"combining tree": func(n int) func(id int) barrier.Barrier {
+			return barrier.NewCombiningTree(n, 2).Participant
+		},
+		"static tree": func(n int) func(id int) barrier.Barrier {
+			return barrier.NewStaticTree(n, 2).Participant
+		},
+		"dissemination": func(n int) func(id int) barrier.Barrier {
+			return barrier.NewDissemination(n).Participant
+		},
+	}
+}
+
+// BenchmarkBarrier runs b.N phases of a fixed number of goroutines, every
+// op is a phase. The WaitGroup variant starts a goroutine per participant
+// for every phase and waits for all of them, as phased jobs often do
+// without a reusable barrier.
+func BenchmarkBarrier(b *testing.B) {
+	// the combining tree needs a power of two
+	n := 2
+	for n < runtime.GOMAXPROCS(0) {
+		n *= 2
+	}
+
+	work := func(id, phase int) int {
+		return id ^ phase
+	}
+
+	b.Run("sync.WaitGroup", func(b *testing.B) {
+		b.ReportAllocs()
+
+		results := make([]int, n)
+		for phase := 0; phase < b.N; phase++ {
+			var wg sync.WaitGroup
+			for id := 0; id < n; id++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					results[id] += work(id, phase)
+				}(id)
+			}
+			wg.Wait()
+		}
+
+		b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "phases/s")
+	})
+
+	for name, newBarrier := range barriers() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			participant := newBarrier(n)
+			results := make([]int, n)
+
+			b.ResetTimer()
+
+			var wg sync.WaitGroup
+			for id := 0; id < n; id++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+
+					p := participant(id)
+					for phase := 0; phase < b.N; phase++ {
+						results[id] += work(id, phase)
+						p.Await()
+					}
+				}(id)
+			}
+			wg.Wait()
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "phases/s")
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package bench
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/util"
+	"github.com/tangledbytes/godc/pkg/counter"
+)
+
+func BenchmarkCounter(b *testing.B) {
+	b.Run("atomic", func(b *testing.B) {
+		var c atomic.Int64
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Add(1)
+			}
+		})
+	})
+
+	b.Run("striped", func(b *testing.B) {
+		c := counter.NewStriped()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Add(1)
+			}
+		})
+	})
+}
+
+func BenchmarkGetAndIncrement(b *testing.B) {
+	procs := runtime.GOMAXPROCS(0)
+	width := util.TreeWidth(procs, procs)
+
+	b.Run("int atomic", func(b *testing.B) {
+		i := atomic.Int64{}
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				i.Add(1)
+			}
+		})
+	})
+
+	b.Run("combining tree", func(b *testing.B) {
+		c := counter.NewCombiningTree(width)
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.GetAndIncrement()
+			}
+		})
+	})
+
+	b.Run("diffracting tree", func(b *testing.B) {
+		c := counter.NewDiffractingTree(width)
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.GetAndIncrement()
+			}
+		})
+	})
+}
@@ -0,0 +1,159 @@
+package bench
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/pkg/ctrie"
+	"github.com/tangledbytes/godc/pkg/hashmap"
+)
+
+// syncMap adapts sync.Map to hashmap.Map to serve as the baseline.
+type syncMap[K comparable, V any] struct {
+	m sync.Map
+}
+
+func (s *syncMap[K, V]) Load(key K) (V, bool) {
+	var def V
+
+	v, ok := s.m.Load(key)
+	if !ok {
+		return def, false
+	}
+
+	return v.(V), true
+}
+
+func (s *syncMap[K, V]) Store(key K, value V) {
+	s.m.Store(key, value)
+}
+
+func (s *syncMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	v, loaded := s.m.LoadOrStore(key, value)
+	return v.(V), loaded
+}
+
+func (s *syncMap[K, V]) Delete(key K) {
+	s.m.Delete(key)
+}
+
+func (s *syncMap[K, V]) Range(f func(key K, value V) bool) {
+	s.m.Range(func(key, value any) bool {
+		return f(key.(K), value.(V))
+	})
+}
+
+func hashMaps() map[string]func() hashmap.Map[int, int] {
+	return map[string]func() hashmap.Map[int, int]{
+		"sync.Map": func() hashmap.Map[int, int] {
+			return &syncMap[int, int]{}
+		},
+		"split ordered": func() hashmap.Map[int, int] {
+			return hashmap.NewSplitOrdered[int, int]()
+		},
+		"striped": func() hashmap.Map[int, int] {
+			return hashmap.NewStriped[int, int]()
+		},
+		"refinable": func() hashmap.Map[int, int] {
+			return hashmap.NewRefinable[int, int]()
+		},
+		"cuckoo": func() hashmap.Map[int, int] {
+			return hashmap.NewCuckoo[int, int]()
+		},
+		"hopscotch": func() hashmap.Map[int, int] {
+			return hashmap.NewHopscotch[int, int]()
+		},
+		"ctrie": func() hashmap.Map[int, int] {
+			return ctrie.New[int, int]()
+		},
+	}
+}
+
+func BenchmarkHashMap(b *testing.B) {
+	const keys = 1 << 16
+
+	// percentage of operations that are reads, the rest are split
+	// evenly between stores and deletes
+	mixes := map[string]int{
+		"read 99%": 99,
+		"read 90%": 90,
+		"read 50%": 50,
+		"read 10%": 10,
+	}
+
+	for name, newMap := range hashMaps() {
+		for mix, reads := range mixes {
+			b.Run(name+" - "+mix, func(b *testing.B) {
+				b.ReportAllocs()
+
+				m := newMap()
+				for i := 0; i < keys; i += 2 {
+					m.Store(i, i)
+				}
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					rng := rand.New(rand.NewSource(rand.Int63()))
+
+					for pb.Next() {
+						key := rng.Intn(keys)
+
+						switch op := rng.Intn(100); {
+						case op < reads:
+							m.Load(key)
+						case op%2 == 0:
+							m.Store(key, key)
+						default:
+							m.Delete(key)
+						}
+					}
+				})
+
+				b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+			})
+		}
+	}
+}
+
+func BenchmarkHashMapMemory(b *testing.B) {
+	sizes := map[string]int{
+		"1K":   1000,
+		"100K": 100000,
+		"1M":   1000000,
+	}
+
+	for name, newMap := range hashMaps() {
+		for size, n := range sizes {
+			b.Run(name+" - "+size, func(b *testing.B) {
+				var total int64
+
+				for i := 0; i < b.N; i++ {
+					var before, after runtime.MemStats
+
+					runtime.GC()
+					runtime.ReadMemStats(&before)
+
+					m := newMap()
+					for k := 0; k < n; k++ {
+						m.Store(k, k)
+					}
+
+					runtime.GC()
+					runtime.ReadMemStats(&after)
+					runtime.KeepAlive(m)
+
+					// the heap may shrink in between, which would wrap
+					// around as an unsigned difference
+					if grown := int64(after.HeapAlloc) - int64(before.HeapAlloc); grown > 0 {
+						total += grown
+					}
+				}
+
+				b.ReportMetric(float64(total)/float64(b.N), "B/map")
+				b.ReportMetric(float64(total)/float64(b.N)/float64(n), "B/entry")
+			})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package bench
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tangledbytes/godc/pkg/locks"
+)
+
+func lockers() map[string]func() sync.Locker {
+	return map[string]func() sync.Locker{
+		"sync.Mutex": func() sync.Locker {
+			return &sync.Mutex{}
+		},
+		"tas": func() sync.Locker {
+			return &locks.TAS{}
+		},
+		"ttas": func() sync.Locker {
+			return &locks.TTAS{}
+		},
+		"backoff": func() sync.Locker {
+			return locks.NewBackoff(time.Microsecond, 100*time.Microsecond)
+		},
+		"anderson": func() sync.Locker {
+			return locks.NewAnderson(1024)
+		},
+		"clh": func() sync.Locker {
+			return &locks.CLH{}
+		},
+		"mcs": func() sync.Locker {
+			return &locks.MCS{}
+		},
+		"to": func() sync.Locker {
+			return &locks.TOLock{}
+		},
+		"composite": func() sync.Locker {
+			return locks.NewComposite(4, time.Microsecond, 100*time.Microsecond)
+		},
+		"cohort": func() sync.Locker {
+			return locks.NewCohort(4, nil)
+		},
+	}
+}
+
+func BenchmarkLocks(b *testing.B) {
+	for name, newLock := range lockers() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			l := newLock()
+			i := 0
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Lock()
+					i++
+					l.Unlock()
+				}
+			})
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+		})
+	}
+}
+
+// BenchmarkLocksFairness has a fixed number of goroutines race for the
+// lock until b.N acquisitions are made between them and reports how
+// unevenly they were spread. A fair lock scores a spread close to 1 and a
+// deviation close to 0.
+func BenchmarkLocksFairness(b *testing.B) {
+	const goroutines = 8
+
+	for name, newLock := range lockers() {
+		b.Run(name, func(b *testing.B) {
+			l := newLock()
+			total := 0
+			counts := make([]int, goroutines)
+
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+
+					for {
+						l.Lock()
+						if total >= b.N {
+							l.Unlock()
+							return
+						}
+						total++
+						counts[g]++
+						l.Unlock()
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			lo, hi := counts[0], counts[0]
+			mean := float64(b.N) / goroutines
+			variance := 0.0
+			for _, c := range counts {
+				if c < lo {
+					lo = c
+				}
+				if c > hi {
+					hi = c
+				}
+				variance += (float64(c) - mean) * (float64(c) - mean) / goroutines
+			}
+
+			b.ReportMetric(float64(hi)/math.Max(float64(lo), 1), "max/min")
+			b.ReportMetric(100*math.Sqrt(variance)/mean, "stddev%")
+		})
+	}
+}
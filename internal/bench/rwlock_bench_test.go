@@ -0,0 +1,71 @@
+package bench
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/pkg/rwlock"
+)
+
+func rwLockers() map[string]func() rwlock.RWLocker {
+	return map[string]func() rwlock.RWLocker{
+		"sync.RWMutex": func() rwlock.RWLocker {
+			return &sync.RWMutex{}
+		},
+		"reader preference": func() rwlock.RWLocker {
+			return rwlock.NewReaderPref()
+		},
+		"writer preference": func() rwlock.RWLocker {
+			return rwlock.NewWriterPref()
+		},
+		"fair": func() rwlock.RWLocker {
+			return rwlock.NewFair()
+		},
+		"bravo": func() rwlock.RWLocker {
+			return rwlock.NewBravo()
+		},
+	}
+}
+
+func BenchmarkRWLocks(b *testing.B) {
+	// percentage of operations that are reads
+	mixes := map[string]int{
+		"read 100%": 100,
+		"read 99%":  99,
+		"read 90%":  90,
+		"read 50%":  50,
+	}
+
+	for name, newLock := range rwLockers() {
+		for mix, reads := range mixes {
+			b.Run(name+" - "+mix, func(b *testing.B) {
+				b.ReportAllocs()
+
+				l := newLock()
+				i := 0
+
+				b.RunParallel(func(pb *testing.PB) {
+					rng := rand.New(rand.NewSource(rand.Int63()))
+
+					sum := 0
+					for pb.Next() {
+						if rng.Intn(100) < reads {
+							l.RLock()
+							sum += i
+							l.RUnlock()
+							continue
+						}
+
+						l.Lock()
+						i++
+						l.Unlock()
+					}
+					_ = sum
+				})
+
+				b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+			})
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package bench
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tangledbytes/godc/pkg/semaphore"
+)
+
+func BenchmarkSemaphore(b *testing.B) {
+	// permits relative to GOMAXPROCS decide how often goroutines wait
+	capacities := map[string]int64{
+		"scarce":    1,
+		"plentiful": 1 << 10,
+	}
+
+	for name, capacity := range capacities {
+		b.Run("chan - "+name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			sem := make(chan struct{}, capacity)
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					sem <- struct{}{}
+					<-sem
+				}
+			})
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+		})
+
+		b.Run("semaphore - "+name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			sem := semaphore.New(capacity)
+			ctx := context.Background()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := sem.Acquire(ctx, 1); err != nil {
+						b.Error(err)
+					}
+					sem.Release(1)
+				}
+			})
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package bench
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/tangledbytes/godc/pkg/skiplist"
+)
+
+func skiplists() map[string]func() skiplist.Map[int, int] {
+	return map[string]func() skiplist.Map[int, int]{
+		"lock free": func() skiplist.Map[int, int] {
+			return skiplist.NewLockFree[int, int]()
+		},
+		"lazy": func() skiplist.Map[int, int] {
+			return skiplist.NewLazy[int, int]()
+		},
+	}
+}
+
+func BenchmarkSkiplist(b *testing.B) {
+	// the key space controls contention, writers to a small one keep
+	// running into each other
+	spaces := map[string]int{
+		"contended":   1 << 6,
+		"uncontended": 1 << 16,
+	}
+
+	// percentage of operations that are reads, the rest are split
+	// evenly between puts and deletes
+	mixes := map[string]int{
+		"read 90%": 90,
+		"read 50%": 50,
+		"read 10%": 10,
+	}
+
+	for name, newMap := range skiplists() {
+		for space, keys := range spaces {
+			for mix, reads := range mixes {
+				b.Run(name+" - "+space+" - "+mix, func(b *testing.B) {
+					b.ReportAllocs()
+
+					m := newMap()
+					for i := 0; i < keys; i += 2 {
+						m.Put(i, i)
+					}
+
+					b.ResetTimer()
+					b.RunParallel(func(pb *testing.PB) {
+						rng := rand.New(rand.NewSource(rand.Int63()))
+
+						for pb.Next() {
+							key := rng.Intn(keys)
+
+							switch op := rng.Intn(100); {
+							case op < reads:
+								m.Contains(key)
+							case op%2 == 0:
+								m.Put(key, key)
+							default:
+								m.Delete(key)
+							}
+						}
+					})
+
+					b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package goid finds out which goroutine is running.
+package goid
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// Current returns the id of the calling goroutine. Go hides it on purpose,
+// so it is parsed from the header of the goroutine's stack trace, which is
+// slow but stable across releases.
+func Current() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+
+	// the trace starts with "goroutine 123 [running]:"
+	b := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		panic("goid: cannot parse goroutine id: " + err.Error())
+	}
+
+	return id
+}
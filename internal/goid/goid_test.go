@@ -0,0 +1,19 @@
+package goid
+
+import "testing"
+
+func Test_Current(t *testing.T) {
+	me := Current()
+	if got := Current(); got != me {
+		t.Errorf("got %v, want %v", got, me)
+	}
+
+	other := make(chan int64)
+	go func() {
+		other <- Current()
+	}()
+
+	if got := <-other; got == me || got <= 0 {
+		t.Errorf("got %v, want a positive id other than %v", got, me)
+	}
+}
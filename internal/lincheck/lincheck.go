@@ -0,0 +1,284 @@
+// Package lincheck checks that histories of concurrent operations are
+// linearizable, that is every operation appears to take effect at some
+// instant between its invocation and its return, in an order a sequential
+// model of the structure accepts.
+//
+// Tests record the operations their goroutines perform with a Recorder
+// and then Check the history against one of the models of this package,
+// or a model of their own.
+//
+// The checker is the algorithm of Wing and Gong with the memoization of
+// Lowe, Testing for Linearizability. Models of structures whose keys are
+// independent of each other, like sets and maps, partition histories by
+// key and check every partition on its own, which Horn and Kroening call
+// P-compositionality. Checking is exponential in the worst case, so
+// histories should be kept to a few thousand operations.
+package lincheck
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Operation is a call which completed, with the logical times of its
+// invocation and return. An operation which returned before another one
+// was invoked must take effect before it.
+type Operation[I, O any] struct {
+	Client int
+	Input  I
+	Output O
+	Call   int64
+	Return int64
+}
+
+// Model is a sequential specification of a structure with states of type
+// S, taking inputs of type I and giving outputs of type O.
+type Model[S, I, O any] struct {
+	// Init returns the initial state.
+	Init func() S
+
+	// Step applies an input to a state and reports whether the structure
+	// could have given the output. Step must not modify the state it is
+	// given, it returns the next state instead.
+	Step func(state S, input I, output O) (bool, S)
+
+	// Equal reports whether two states are the same. It defaults to
+	// reflect.DeepEqual.
+	Equal func(a, b S) bool
+
+	// Partition optionally splits a history into histories which can be
+	// checked independently.
+	Partition func(history []Operation[I, O]) [][]Operation[I, O]
+}
+
+// Recorder records the history of operations performed by concurrent
+// goroutines.
+type Recorder[I, O any] struct {
+	clock atomic.Int64
+
+	mu      sync.Mutex
+	history []Operation[I, O]
+}
+
+func NewRecorder[I, O any]() *Recorder[I, O] {
+	return &Recorder[I, O]{}
+}
+
+// Record performs an operation by calling f and records it along with the
+// times f was called and returned.
+func (r *Recorder[I, O]) Record(client int, input I, f func() O) O {
+	call := r.clock.Add(1)
+	output := f()
+	ret := r.clock.Add(1)
+
+	r.mu.Lock()
+	r.history = append(r.history, Operation[I, O]{
+		Client: client,
+		Input:  input,
+		Output: output,
+		Call:   call,
+		Return: ret,
+	})
+	r.mu.Unlock()
+
+	return output
+}
+
+// History returns the operations recorded so far.
+func (r *Recorder[I, O]) History() []Operation[I, O] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Operation[I, O](nil), r.history...)
+}
+
+// Check reports whether the history is linearizable with respect to the
+// model.
+func Check[S, I, O any](model Model[S, I, O], history []Operation[I, O]) bool {
+	if model.Equal == nil {
+		model.Equal = func(a, b S) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+
+	partitions := [][]Operation[I, O]{history}
+	if model.Partition != nil {
+		partitions = model.Partition(history)
+	}
+
+	for _, partition := range partitions {
+		if !checkPartition(model, partition) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// entry is the invocation or the return of an operation. Entries form a
+// doubly linked list ordered by time.
+type entry struct {
+	op    int
+	call  bool
+	time  int64
+	match *entry
+
+	prev, next *entry
+}
+
+// lift takes an operation, given by its call, out of the list.
+func (e *entry) lift() {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	ret := e.match
+	ret.prev.next = ret.next
+	if ret.next != nil {
+		ret.next.prev = ret.prev
+	}
+}
+
+// unlift puts an operation lifted before back into the list.
+func (e *entry) unlift() {
+	ret := e.match
+	ret.prev.next = ret
+	if ret.next != nil {
+		ret.next.prev = ret
+	}
+
+	e.prev.next = e
+	e.next.prev = e
+}
+
+func buildList[I, O any](history []Operation[I, O]) *entry {
+	entries := make([]*entry, 0, 2*len(history))
+	for i, op := range history {
+		call := &entry{op: i, call: true, time: op.Call}
+		ret := &entry{op: i, time: op.Return}
+		call.match = ret
+
+		entries = append(entries, call, ret)
+	}
+
+	// calls go before returns at the same time so that operations which
+	// may have overlapped are treated as concurrent
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].time != entries[j].time {
+			return entries[i].time < entries[j].time
+		}
+		return entries[i].call && !entries[j].call
+	})
+
+	head := &entry{}
+	prev := head
+	for _, e := range entries {
+		prev.next = e
+		e.prev = prev
+		prev = e
+	}
+
+	return head
+}
+
+type bitset []uint64
+
+func (b bitset) set(i int)   { b[i/64] |= 1 << (i % 64) }
+func (b bitset) clear(i int) { b[i/64] &^= 1 << (i % 64) }
+
+func (b bitset) hash() uint64 {
+	// FNV-1a over the words
+	h := uint64(14695981039346656037)
+	for _, w := range b {
+		h ^= w
+		h *= 1099511628211
+	}
+
+	return h
+}
+
+func (b bitset) equal(other bitset) bool {
+	for i := range b {
+		if b[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type cached[S any] struct {
+	linearized bitset
+	state      S
+}
+
+type frame[S any] struct {
+	call  *entry
+	state S
+}
+
+func checkPartition[S, I, O any](model Model[S, I, O], history []Operation[I, O]) bool {
+	head := buildList(history)
+
+	linearized := make(bitset, (len(history)+63)/64)
+	cache := make(map[uint64][]cached[S])
+	var stack []frame[S]
+
+	// seen reports whether the same operations were linearized before
+	// ending up in the same state, and remembers them otherwise
+	seen := func(linearized bitset, state S) bool {
+		h := linearized.hash()
+		for _, c := range cache[h] {
+			if c.linearized.equal(linearized) && model.Equal(c.state, state) {
+				return true
+			}
+		}
+
+		cache[h] = append(cache[h], cached[S]{
+			linearized: append(bitset(nil), linearized...),
+			state:      state,
+		})
+
+		return false
+	}
+
+	state := model.Init()
+	e := head.next
+	for head.next != nil {
+		if !e.call {
+			// the earliest pending return was reached without being able
+			// to linearize the operation, undo the last choice
+			if len(stack) == 0 {
+				return false
+			}
+
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			state = top.state
+			linearized.clear(top.call.op)
+			top.call.unlift()
+			e = top.call.next
+			continue
+		}
+
+		op := history[e.op]
+		ok, next := model.Step(state, op.Input, op.Output)
+		if ok {
+			linearized.set(e.op)
+			if !seen(linearized, next) {
+				stack = append(stack, frame[S]{call: e, state: state})
+				state = next
+				e.lift()
+				e = head.next
+				continue
+			}
+			linearized.clear(e.op)
+		}
+
+		e = e.next
+	}
+
+	return true
+}
@@ -0,0 +1,326 @@
+package lincheck
+
+import (
+	"sync"
+	"testing"
+)
+
+type queueOp = Operation[ValueInput[int], ValueOutput[int]]
+
+func push(value int, call, ret int64) queueOp {
+	return queueOp{Input: ValueInput[int]{Op: Push, Value: value}, Call: call, Return: ret}
+}
+
+func pop(value int, ok bool, call, ret int64) queueOp {
+	return queueOp{
+		Input:  ValueInput[int]{Op: Pop},
+		Output: ValueOutput[int]{Value: value, Ok: ok},
+		Call:   call,
+		Return: ret,
+	}
+}
+
+func peek(value int, ok bool, call, ret int64) queueOp {
+	op := pop(value, ok, call, ret)
+	op.Input.Op = Peek
+	return op
+}
+
+func Test_Check_Queue(t *testing.T) {
+	type test struct {
+		name    string
+		history []queueOp
+		want    bool
+	}
+
+	tests := []test{
+		{
+			name:    "empty",
+			history: nil,
+			want:    true,
+		},
+		{
+			name: "sequential",
+			history: []queueOp{
+				push(1, 1, 2),
+				push(2, 3, 4),
+				pop(1, true, 5, 6),
+				pop(2, true, 7, 8),
+				pop(0, false, 9, 10),
+			},
+			want: true,
+		},
+		{
+			name: "sequential - out of order",
+			history: []queueOp{
+				push(1, 1, 2),
+				push(2, 3, 4),
+				pop(2, true, 5, 6),
+				pop(1, true, 7, 8),
+			},
+			want: false,
+		},
+		{
+			name: "concurrent pushes - either order",
+			history: []queueOp{
+				push(1, 1, 4),
+				push(2, 2, 3),
+				pop(2, true, 5, 6),
+				pop(1, true, 7, 8),
+			},
+			want: true,
+		},
+		{
+			name: "pop overlapping push",
+			history: []queueOp{
+				push(1, 1, 4),
+				pop(1, true, 2, 3),
+			},
+			want: true,
+		},
+		{
+			name: "empty pop after push",
+			history: []queueOp{
+				push(1, 1, 2),
+				pop(0, false, 3, 4),
+			},
+			want: false,
+		},
+		{
+			name: "peek leaves the value",
+			history: []queueOp{
+				push(1, 1, 2),
+				peek(1, true, 3, 4),
+				peek(1, true, 5, 6),
+				pop(1, true, 7, 8),
+				peek(0, false, 9, 10),
+			},
+			want: true,
+		},
+		{
+			name: "peek past the head",
+			history: []queueOp{
+				push(1, 1, 2),
+				push(2, 3, 4),
+				peek(2, true, 5, 6),
+			},
+			want: false,
+		},
+		{
+			name: "value popped twice",
+			history: []queueOp{
+				push(1, 1, 2),
+				pop(1, true, 3, 6),
+				pop(1, true, 4, 5),
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(QueueModel[int](), tt.history); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Check_Stack(t *testing.T) {
+	history := []queueOp{
+		push(1, 1, 2),
+		push(2, 3, 4),
+		peek(2, true, 5, 6),
+		pop(2, true, 7, 8),
+		pop(1, true, 9, 10),
+	}
+
+	if got := Check(StackModel[int](), history); !got {
+		t.Errorf("got %v, want %v", got, true)
+	}
+
+	if got := Check(QueueModel[int](), history); got {
+		t.Errorf("got %v, want %v", got, false)
+	}
+}
+
+func Test_Check_Set(t *testing.T) {
+	type setOp = Operation[SetInput[int], bool]
+
+	op := func(o Op, key int, output bool, call, ret int64) setOp {
+		return setOp{Input: SetInput[int]{Op: o, Key: key}, Output: output, Call: call, Return: ret}
+	}
+
+	type test struct {
+		name    string
+		history []setOp
+		want    bool
+	}
+
+	tests := []test{
+		{
+			name: "independent keys",
+			history: []setOp{
+				op(Add, 1, true, 1, 2),
+				op(Add, 2, true, 3, 4),
+				op(Contains, 1, true, 5, 6),
+				op(Remove, 2, true, 7, 8),
+				op(Contains, 2, false, 9, 10),
+			},
+			want: true,
+		},
+		{
+			name: "concurrent adds both succeed",
+			history: []setOp{
+				op(Add, 1, true, 1, 4),
+				op(Add, 1, true, 2, 3),
+			},
+			want: false,
+		},
+		{
+			name: "remove of missing key",
+			history: []setOp{
+				op(Add, 1, true, 1, 2),
+				op(Remove, 2, true, 3, 4),
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(SetModel[int](), tt.history); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Check_Map(t *testing.T) {
+	type mapOp = Operation[MapInput[int, int], MapOutput[int]]
+
+	op := func(o Op, key, value int, output MapOutput[int], call, ret int64) mapOp {
+		return mapOp{Input: MapInput[int, int]{Op: o, Key: key, Value: value}, Output: output, Call: call, Return: ret}
+	}
+
+	type test struct {
+		name    string
+		history []mapOp
+		want    bool
+	}
+
+	tests := []test{
+		{
+			name: "load or store",
+			history: []mapOp{
+				op(LoadOrStore, 1, 10, MapOutput[int]{Value: 10}, 1, 4),
+				op(LoadOrStore, 1, 20, MapOutput[int]{Value: 10, Ok: true}, 2, 3),
+				op(Load, 1, 0, MapOutput[int]{Value: 10, Ok: true}, 5, 6),
+			},
+			want: true,
+		},
+		{
+			name: "both stored",
+			history: []mapOp{
+				op(LoadOrStore, 1, 10, MapOutput[int]{Value: 10}, 1, 4),
+				op(LoadOrStore, 1, 20, MapOutput[int]{Value: 20}, 2, 3),
+			},
+			want: false,
+		},
+		{
+			name: "stale load",
+			history: []mapOp{
+				op(Store, 1, 10, MapOutput[int]{}, 1, 2),
+				op(Store, 1, 20, MapOutput[int]{}, 3, 4),
+				op(Load, 1, 0, MapOutput[int]{Value: 10, Ok: true}, 5, 6),
+			},
+			want: false,
+		},
+		{
+			name: "load after delete",
+			history: []mapOp{
+				op(Store, 1, 10, MapOutput[int]{}, 1, 2),
+				op(Delete, 1, 0, MapOutput[int]{}, 3, 6),
+				op(Load, 1, 0, MapOutput[int]{Value: 10, Ok: true}, 4, 5),
+				op(Load, 1, 0, MapOutput[int]{}, 7, 8),
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(MapModel[int, int](), tt.history); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Recorder(t *testing.T) {
+	const goroutines, ops = 4, 100
+
+	// a queue guarded by a mutex is linearizable, one which pops from
+	// the wrong end is not, at least once it held two values
+	type queue struct {
+		mu    sync.Mutex
+		items []int
+		lifo  bool
+	}
+
+	run := func(q *queue) []queueOp {
+		r := NewRecorder[ValueInput[int], ValueOutput[int]]()
+
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+
+				for i := 0; i < ops; i++ {
+					value := g*ops + i
+					r.Record(g, ValueInput[int]{Op: Push, Value: value}, func() ValueOutput[int] {
+						q.mu.Lock()
+						defer q.mu.Unlock()
+
+						q.items = append(q.items, value)
+						return ValueOutput[int]{}
+					})
+
+					r.Record(g, ValueInput[int]{Op: Pop}, func() ValueOutput[int] {
+						q.mu.Lock()
+						defer q.mu.Unlock()
+
+						if len(q.items) == 0 {
+							return ValueOutput[int]{}
+						}
+
+						if q.lifo {
+							v := q.items[len(q.items)-1]
+							q.items = q.items[:len(q.items)-1]
+							return ValueOutput[int]{Value: v, Ok: true}
+						}
+
+						v := q.items[0]
+						q.items = q.items[1:]
+						return ValueOutput[int]{Value: v, Ok: true}
+					})
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		return r.History()
+	}
+
+	if history := run(&queue{}); !Check(QueueModel[int](), history) {
+		t.Errorf("FIFO queue not linearizable")
+	}
+
+	// make sure the LIFO queue holds two values at some point
+	lifo := &queue{lifo: true, items: []int{-1}}
+	history := append(run(lifo), push(-1, -1, 0))
+	if Check(QueueModel[int](), history) {
+		t.Errorf("LIFO queue linearizable as a FIFO queue")
+	}
+}
@@ -0,0 +1,229 @@
+package lincheck
+
+// Op names the operation of a call to one of the modelled structures.
+type Op int
+
+const (
+	// Push, Pop and Peek are the operations of queues and stacks.
+	Push Op = iota
+	Pop
+	Peek
+
+	// Add, Remove and Contains are the operations of sets.
+	Add
+	Remove
+	Contains
+
+	// Load, Store, LoadOrStore and Delete are the operations of maps,
+	// they behave like those of sync.Map.
+	Load
+	Store
+	LoadOrStore
+	Delete
+)
+
+// ValueInput is a call to a queue or a stack. Value is only used by Push.
+type ValueInput[T any] struct {
+	Op    Op
+	Value T
+}
+
+// ValueOutput is the result of a call to a queue or a stack. Pop and Peek
+// report whether there was a value and Push's result is ignored.
+type ValueOutput[T any] struct {
+	Value T
+	Ok    bool
+}
+
+// QueueModel returns the model of a FIFO queue.
+func QueueModel[T comparable]() Model[[]T, ValueInput[T], ValueOutput[T]] {
+	return Model[[]T, ValueInput[T], ValueOutput[T]]{
+		Init: func() []T {
+			return nil
+		},
+		Step: func(state []T, input ValueInput[T], output ValueOutput[T]) (bool, []T) {
+			switch input.Op {
+			case Push:
+				next := make([]T, len(state), len(state)+1)
+				copy(next, state)
+				return true, append(next, input.Value)
+			case Pop:
+				if len(state) == 0 {
+					return !output.Ok, state
+				}
+				return output.Ok && output.Value == state[0], state[1:]
+			case Peek:
+				if len(state) == 0 {
+					return !output.Ok, state
+				}
+				return output.Ok && output.Value == state[0], state
+			default:
+				panic("lincheck: unexpected queue operation")
+			}
+		},
+		Equal: equalSlices[T],
+	}
+}
+
+// StackModel returns the model of a LIFO stack.
+func StackModel[T comparable]() Model[[]T, ValueInput[T], ValueOutput[T]] {
+	return Model[[]T, ValueInput[T], ValueOutput[T]]{
+		Init: func() []T {
+			return nil
+		},
+		Step: func(state []T, input ValueInput[T], output ValueOutput[T]) (bool, []T) {
+			switch input.Op {
+			case Push:
+				next := make([]T, len(state), len(state)+1)
+				copy(next, state)
+				return true, append(next, input.Value)
+			case Pop:
+				if len(state) == 0 {
+					return !output.Ok, state
+				}
+				top := len(state) - 1
+				return output.Ok && output.Value == state[top], state[:top]
+			case Peek:
+				if len(state) == 0 {
+					return !output.Ok, state
+				}
+				return output.Ok && output.Value == state[len(state)-1], state
+			default:
+				panic("lincheck: unexpected stack operation")
+			}
+		},
+		Equal: equalSlices[T],
+	}
+}
+
+// SetInput is a call to a set. Add and Remove output whether they changed
+// the set and Contains whether the key was present.
+type SetInput[K any] struct {
+	Op  Op
+	Key K
+}
+
+// SetModel returns the model of a set. Keys are independent, histories are
+// checked one key at a time and the state is whether the key is present.
+func SetModel[K comparable]() Model[bool, SetInput[K], bool] {
+	return Model[bool, SetInput[K], bool]{
+		Init: func() bool {
+			return false
+		},
+		Step: func(present bool, input SetInput[K], output bool) (bool, bool) {
+			switch input.Op {
+			case Add:
+				return output == !present, true
+			case Remove:
+				return output == present, false
+			case Contains:
+				return output == present, present
+			default:
+				panic("lincheck: unexpected set operation")
+			}
+		},
+		Equal: func(a, b bool) bool {
+			return a == b
+		},
+		Partition: func(history []Operation[SetInput[K], bool]) [][]Operation[SetInput[K], bool] {
+			return partitionBy(history, func(input SetInput[K]) K {
+				return input.Key
+			})
+		},
+	}
+}
+
+// MapInput is a call to a map. Value is only used by Store and
+// LoadOrStore.
+type MapInput[K, V any] struct {
+	Op    Op
+	Key   K
+	Value V
+}
+
+// MapOutput is the result of a call to a map. Load outputs the value and
+// whether it was present, LoadOrStore the actual value and whether it was
+// loaded. The results of Store and Delete are ignored.
+type MapOutput[V any] struct {
+	Value V
+	Ok    bool
+}
+
+// MapState is the state of a single key of a map.
+type MapState[V any] struct {
+	Value   V
+	Present bool
+}
+
+// MapModel returns the model of a map. Keys are independent, histories are
+// checked one key at a time.
+func MapModel[K, V comparable]() Model[MapState[V], MapInput[K, V], MapOutput[V]] {
+	return Model[MapState[V], MapInput[K, V], MapOutput[V]]{
+		Init: func() MapState[V] {
+			return MapState[V]{}
+		},
+		Step: func(state MapState[V], input MapInput[K, V], output MapOutput[V]) (bool, MapState[V]) {
+			switch input.Op {
+			case Load:
+				if !state.Present {
+					return !output.Ok, state
+				}
+				return output.Ok && output.Value == state.Value, state
+			case Store:
+				return true, MapState[V]{Value: input.Value, Present: true}
+			case LoadOrStore:
+				if state.Present {
+					return output.Ok && output.Value == state.Value, state
+				}
+				return !output.Ok && output.Value == input.Value, MapState[V]{Value: input.Value, Present: true}
+			case Delete:
+				return true, MapState[V]{}
+			default:
+				panic("lincheck: unexpected map operation")
+			}
+		},
+		Equal: func(a, b MapState[V]) bool {
+			return a == b
+		},
+		Partition: func(history []Operation[MapInput[K, V], MapOutput[V]]) [][]Operation[MapInput[K, V], MapOutput[V]] {
+			return partitionBy(history, func(input MapInput[K, V]) K {
+				return input.Key
+			})
+		},
+	}
+}
+
+func equalSlices[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// partitionBy splits a history by the key of every operation.
+func partitionBy[K comparable, I, O any](history []Operation[I, O], key func(I) K) [][]Operation[I, O] {
+	index := make(map[K]int)
+
+	var partitions [][]Operation[I, O]
+	for _, op := range history {
+		k := key(op.Input)
+
+		i, ok := index[k]
+		if !ok {
+			i = len(partitions)
+			index[k] = i
+			partitions = append(partitions, nil)
+		}
+
+		partitions[i] = append(partitions[i], op)
+	}
+
+	return partitions
+}
@@ -0,0 +1,18 @@
+// Package sched explores the interleavings of goroutines deterministically.
+//
+// Data structures mark the points where a switch to another goroutine is
+// interesting, typically between loading a value and the CAS that depends
+// on it, by calling Yield. Built with the godc_sched tag, goroutines run by
+// this package only ever run one at a time and hand control back at every
+// Yield, letting a strategy decide which one runs next. Without the tag
+// Yield does nothing.
+//
+// Every run is described by its schedule, the goroutine picked at every
+// step, so that a failing run can be replayed exactly and shrunk to fewer
+// context switches. This requires the program to be deterministic apart
+// from the scheduling, and goroutines waiting for each other to Yield in
+// their wait loops.
+//
+// Only one program can be explored at a time in a process, tests using
+// this package must not run in parallel.
+package sched
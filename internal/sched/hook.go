@@ -0,0 +1,7 @@
+//go:build !godc_sched
+
+package sched
+
+// Yield marks a point where the scheduler may switch to another goroutine.
+// Without the godc_sched build tag it does nothing and is compiled away.
+func Yield(point string) {}
@@ -0,0 +1,300 @@
+//go:build godc_sched
+
+package sched
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/goid"
+)
+
+// Program builds a fresh instance of the code under test for every run. It
+// returns the bodies of the goroutines to interleave and a check of the
+// final state, run once they have all returned.
+type Program func() (threads []func(), check func() error)
+
+// Failure is a run which failed its check.
+type Failure struct {
+	// Seed is the seed of the run if it was found by Random.
+	Seed int64
+
+	// Schedule is the goroutine picked at every step of the run. Shrunk
+	// failures only keep the steps that matter, past those Replay lets the
+	// goroutines take turns.
+	Schedule []int
+
+	// Trace is the yield point every step of the run stopped at.
+	Trace []string
+
+	Err error
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("sched: %v (seed %v, schedule %v)", f.Err, f.Seed, f.Schedule)
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// current is the scheduler of the run in progress, if any.
+var current atomic.Pointer[scheduler]
+
+// Yield marks a point where the scheduler may switch to another goroutine.
+// Goroutines which are not run by this package return right away.
+func Yield(point string) {
+	s := current.Load()
+	if s == nil {
+		return
+	}
+
+	t := s.lookup(goid.Current())
+	if t == nil {
+		return
+	}
+
+	t.point = point
+	s.parked <- struct{}{}
+	<-t.wake
+}
+
+// chooser picks the index of the next goroutine out of the runnable ones at
+// the given step. prev is the index of the goroutine which ran last.
+type chooser func(step int, runnable []int, prev int) int
+
+// roundRobin switches to the next goroutine after the one which ran last,
+// which is fair to goroutines spinning while waiting for another one.
+func roundRobin(runnable []int, prev int) int {
+	for i, id := range runnable {
+		if id > prev {
+			return i
+		}
+	}
+
+	return 0
+}
+
+type thread struct {
+	id    int
+	wake  chan struct{}
+	done  bool
+	point string
+}
+
+type scheduler struct {
+	mu      sync.Mutex
+	threads map[int64]*thread
+
+	parked chan struct{}
+}
+
+func (s *scheduler) lookup(id int64) *thread {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.threads[id]
+}
+
+// run runs the program once, asking choose for the goroutine to run at
+// every step, and returns the failure if its check failed.
+func run(p Program, choose chooser) *Failure {
+	bodies, check := p()
+
+	s := &scheduler{
+		threads: make(map[int64]*thread),
+		parked:  make(chan struct{}),
+	}
+	if !current.CompareAndSwap(nil, s) {
+		panic("sched: programs cannot be explored concurrently")
+	}
+	defer current.Store(nil)
+
+	threads := make([]*thread, len(bodies))
+	panics := make([]any, len(bodies))
+
+	for i, body := range bodies {
+		t := &thread{id: i, wake: make(chan struct{})}
+		threads[i] = t
+
+		go func(i int, body func()) {
+			defer func() {
+				panics[i] = recover()
+				t.done = true
+				s.parked <- struct{}{}
+			}()
+
+			s.mu.Lock()
+			s.threads[goid.Current()] = t
+			s.mu.Unlock()
+
+			s.parked <- struct{}{}
+			<-t.wake
+
+			body()
+		}(i, body)
+	}
+
+	// wait for every goroutine to be ready
+	for range threads {
+		<-s.parked
+	}
+
+	f := &Failure{}
+	prev := -1
+	for step := 0; ; step++ {
+		var runnable []int
+		for _, t := range threads {
+			if !t.done {
+				runnable = append(runnable, t.id)
+			}
+		}
+		if len(runnable) == 0 {
+			break
+		}
+
+		t := threads[runnable[choose(step, runnable, prev)]]
+		prev = t.id
+
+		f.Schedule = append(f.Schedule, t.id)
+		t.wake <- struct{}{}
+		<-s.parked
+
+		point := t.point
+		if t.done {
+			point = "return"
+		}
+		f.Trace = append(f.Trace, fmt.Sprintf("%v: %v", t.id, point))
+	}
+
+	for i, r := range panics {
+		if r != nil {
+			f.Err = fmt.Errorf("goroutine %v panicked: %v", i, r)
+			return f
+		}
+	}
+
+	if err := runCheck(check); err != nil {
+		f.Err = err
+		return f
+	}
+
+	return nil
+}
+
+// runCheck runs check, reporting a panic as an error since a broken data
+// structure often fails that way.
+func runCheck(check func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+
+	return check()
+}
+
+// Random runs the program with as many random schedules, the i-th run
+// seeded with seed+i, and returns the first failure.
+func Random(p Program, seed int64, runs int) *Failure {
+	for i := 0; i < runs; i++ {
+		rng := rand.New(rand.NewSource(seed + int64(i)))
+
+		f := run(p, func(_ int, runnable []int, _ int) int {
+			return rng.Intn(len(runnable))
+		})
+		if f != nil {
+			f.Seed = seed + int64(i)
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Exhaustive runs the program with every schedule that differs in one of
+// the first depth steps, in depth first order, and returns the first
+// failure. After depth steps the goroutines take turns. At most
+// maxRuns runs are made, or as many as it takes if it is zero.
+func Exhaustive(p Program, depth, maxRuns int) *Failure {
+	type choice struct {
+		index, options int
+	}
+
+	var stack []choice
+	for runs := 0; maxRuns == 0 || runs < maxRuns; runs++ {
+		f := run(p, func(step int, runnable []int, prev int) int {
+			if step >= depth {
+				return roundRobin(runnable, prev)
+			}
+
+			if step == len(stack) {
+				stack = append(stack, choice{options: len(runnable)})
+			}
+
+			return stack[step].index
+		})
+		if f != nil {
+			return f
+		}
+
+		// move on to the next schedule
+		for len(stack) > 0 && stack[len(stack)-1].index+1 == stack[len(stack)-1].options {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			return nil
+		}
+		stack[len(stack)-1].index++
+	}
+
+	return nil
+}
+
+// Replay runs the program with the given schedule. Where the schedule
+// ends or picks a goroutine which already returned, the goroutines take
+// turns.
+func Replay(p Program, schedule []int) *Failure {
+	return run(p, func(step int, runnable []int, prev int) int {
+		if step < len(schedule) {
+			for i, id := range runnable {
+				if id == schedule[step] {
+					return i
+				}
+			}
+		}
+
+		return roundRobin(runnable, prev)
+	})
+}
+
+// Shrink looks for a shorter schedule which still fails, by repeatedly
+// dropping single steps of it and replaying the rest. It returns the
+// shortest failure found, which is f itself if no step could be dropped.
+func Shrink(p Program, f *Failure) *Failure {
+	best := f
+	schedule := f.Schedule
+
+	for shrunk := true; shrunk; {
+		shrunk = false
+
+		for i := len(schedule) - 1; i >= 0; i-- {
+			candidate := make([]int, 0, len(schedule)-1)
+			candidate = append(candidate, schedule[:i]...)
+			candidate = append(candidate, schedule[i+1:]...)
+
+			if g := Replay(p, candidate); g != nil {
+				schedule = candidate
+				g.Seed = f.Seed
+				best = g
+				shrunk = true
+			}
+		}
+	}
+
+	// only keep the steps that were forced
+	best.Schedule = schedule
+	return best
+}
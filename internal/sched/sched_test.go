@@ -0,0 +1,137 @@
+//go:build godc_sched
+
+package sched
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// lostUpdate is a counter incremented by two goroutines without any
+// synchronisation, which loses an update if they interleave.
+func lostUpdate() (threads []func(), check func() error) {
+	x := 0
+	inc := func() {
+		v := x
+		Yield("loaded")
+		x = v + 1
+	}
+
+	return []func(){inc, inc}, func() error {
+		if x != 2 {
+			return fmt.Errorf("got %v, want %v", x, 2)
+		}
+		return nil
+	}
+}
+
+func atomicIncrements() (threads []func(), check func() error) {
+	var x atomic.Int64
+	inc := func() {
+		Yield("before add")
+		x.Add(1)
+		Yield("after add")
+	}
+
+	return []func(){inc, inc, inc}, func() error {
+		if got := x.Load(); got != 3 {
+			return fmt.Errorf("got %v, want %v", got, 3)
+		}
+		return nil
+	}
+}
+
+func Test_Random(t *testing.T) {
+	f := Random(lostUpdate, 1, 100)
+	if f == nil {
+		t.Fatalf("lost update not found")
+	}
+
+	// the seed reproduces the failure
+	if g := Random(lostUpdate, f.Seed, 1); g == nil || !reflect.DeepEqual(g.Schedule, f.Schedule) {
+		t.Errorf("got %v, want %v", g, f)
+	}
+
+	if f := Random(atomicIncrements, 1, 100); f != nil {
+		t.Errorf("got %v, want %v", f, nil)
+	}
+}
+
+func Test_Exhaustive(t *testing.T) {
+	f := Exhaustive(lostUpdate, 10, 0)
+	if f == nil {
+		t.Fatalf("lost update not found")
+	}
+
+	if f := Exhaustive(atomicIncrements, 10, 0); f != nil {
+		t.Errorf("got %v, want %v", f, nil)
+	}
+
+	// every schedule is explored exactly once
+	seen := make(map[string]bool)
+	count := func() (threads []func(), check func() error) {
+		var order []int
+		body := func(id int) func() {
+			return func() {
+				order = append(order, id)
+				Yield("appended")
+				order = append(order, id)
+			}
+		}
+
+		return []func(){body(0), body(1)}, func() error {
+			key := fmt.Sprint(order)
+			if seen[key] {
+				return fmt.Errorf("schedule %v explored twice", key)
+			}
+			seen[key] = true
+			return nil
+		}
+	}
+
+	if f := Exhaustive(count, 10, 0); f != nil {
+		t.Fatalf("got %v, want %v", f, nil)
+	}
+
+	// two goroutines of two steps each interleave in 4 choose 2 ways
+	if len(seen) != 6 {
+		t.Errorf("got %v schedules, want %v", len(seen), 6)
+	}
+}
+
+func Test_ReplayShrink(t *testing.T) {
+	f := Random(lostUpdate, 1, 100)
+	if f == nil {
+		t.Fatalf("lost update not found")
+	}
+
+	g := Replay(lostUpdate, f.Schedule)
+	if g == nil {
+		t.Fatalf("replay did not fail")
+	}
+
+	if !reflect.DeepEqual(g.Trace, f.Trace) {
+		t.Errorf("got %v, want %v", g.Trace, f.Trace)
+	}
+
+	shrunk := Shrink(lostUpdate, f)
+	if len(shrunk.Schedule) > len(f.Schedule) {
+		t.Errorf("got %v, want at most %v steps", len(shrunk.Schedule), len(f.Schedule))
+	}
+
+	if Replay(lostUpdate, shrunk.Schedule) == nil {
+		t.Errorf("shrunk schedule %v does not fail", shrunk.Schedule)
+	}
+}
+
+func Test_Panic(t *testing.T) {
+	f := Random(func() ([]func(), func() error) {
+		return []func(){func() { panic("boom") }}, func() error { return nil }
+	}, 1, 1)
+
+	if f == nil {
+		t.Fatalf("panic not reported")
+	}
+}
@@ -0,0 +1,37 @@
+// Package spin provides the wait loop of the spin locks, reader-writer
+// locks and barriers.
+//
+// Go does not let a goroutine pin itself to a CPU, so a spinning goroutine
+// could keep the one it waits for from running. To avoid that a Spinner
+// spins for a short while and then yields the processor on every
+// iteration. Every iteration is also a point where the schedule
+// exploration of internal/sched may switch goroutines.
+package spin
+
+import (
+	"runtime"
+
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+// spinsBeforeYield is how many times a waiter spins before it starts
+// yielding.
+const spinsBeforeYield = 64
+
+// Spinner counts the iterations of a wait loop. The zero value is ready to
+// use and a Spinner must not be shared between goroutines.
+type Spinner struct {
+	n int
+}
+
+// Spin is called on every iteration of a wait loop.
+func (s *Spinner) Spin() {
+	sched.Yield("spin")
+
+	if s.n < spinsBeforeYield {
+		s.n++
+		return
+	}
+
+	runtime.Gosched()
+}
@@ -0,0 +1,92 @@
+package stress
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/tangledbytes/godc/internal/workload"
+)
+
+// mapChecker gives goroutine g of a round with n goroutines the keys g,
+// g+n, g+2n and so on. Only the owner of a key touches it during a round,
+// so present always tells whether a key is in the map and the result of
+// every operation is checked as soon as it returns.
+type mapChecker struct {
+	m     workload.Target
+	lener workload.Lener
+
+	stride int
+
+	// present is only written by the owner of a key, distinct elements of
+	// a slice can be written concurrently
+	present []bool
+}
+
+func newMapChecker(m workload.Target, keys int) *mapChecker {
+	lener, _ := m.(workload.Lener)
+
+	return &mapChecker{
+		m:       m,
+		lener:   lener,
+		present: make([]bool, keys),
+	}
+}
+
+func (c *mapChecker) begin(r Round) {
+	c.stride = r.Goroutines
+}
+
+func (c *mapChecker) op(g int, op workload.Op, rng *rand.Rand) (Record, error) {
+	owned := (len(c.present) - g + c.stride - 1) / c.stride
+	key := g + c.stride*rng.Intn(owned)
+
+	switch op {
+	case workload.Insert:
+		c.m.Insert(key)
+		c.present[key] = true
+
+		return Record{Op: op, Value: key}, nil
+	case workload.Lookup:
+		rec := Record{Op: op, Value: key, Ok: c.m.Lookup(key)}
+		if rec.Ok != c.present[key] {
+			return rec, fmt.Errorf("got %v, want %v", rec, c.present[key])
+		}
+
+		return rec, nil
+	default:
+		value, ok := c.m.Remove(key)
+		rec := Record{Op: op, Value: key, Result: value, Ok: ok}
+		if ok != c.present[key] || (ok && value != key) {
+			want := Record{Op: op, Value: key, Ok: c.present[key]}
+			if want.Ok {
+				want.Result = key
+			}
+
+			return rec, fmt.Errorf("got %v, want %v", rec, want)
+		}
+		c.present[key] = false
+
+		return rec, nil
+	}
+}
+
+func (c *mapChecker) check(Round, [][]Record) error {
+	want := 0
+	for key, present := range c.present {
+		if present {
+			want++
+		}
+
+		if got := c.m.Lookup(key); got != present {
+			return fmt.Errorf("lookup %v: got %v, want %v", key, got, present)
+		}
+	}
+
+	if c.lener != nil {
+		if got := c.lener.Len(); got != int64(want) {
+			return fmt.Errorf("got length %v, want %v", got, want)
+		}
+	}
+
+	return nil
+}
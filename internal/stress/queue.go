@@ -0,0 +1,172 @@
+package stress
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/tangledbytes/godc/internal/workload"
+)
+
+// maxRefill bounds the number of items pushed back after a check, beyond
+// it the queue is left empty so that a test which mostly pushes does not
+// grow without bounds.
+const maxRefill = 1 << 16
+
+// origin is the goroutine which pushed an item and the round it did.
+type origin struct {
+	round     int
+	goroutine int
+}
+
+func (o origin) String() string {
+	return fmt.Sprintf("goroutine %v of round %v", o.goroutine, o.round)
+}
+
+// queueChecker makes every item unique: goroutine g of a round with n
+// goroutines pushes base+g, base+g+n, base+g+2n and so on, so the items
+// of a goroutine also increase in the order it pushes them.
+type queueChecker struct {
+	q     workload.Target
+	lener workload.Lener
+
+	base   int
+	stride int
+
+	// pushed counts the items pushed by every goroutine of the round
+	pushed []int
+
+	// items maps the items in the queue to where they came from
+	items map[int]origin
+}
+
+func newQueueChecker(q workload.Target) *queueChecker {
+	lener, _ := q.(workload.Lener)
+
+	return &queueChecker{
+		q:     q,
+		lener: lener,
+		items: make(map[int]origin),
+	}
+}
+
+func (c *queueChecker) begin(r Round) {
+	c.stride = r.Goroutines
+	c.pushed = make([]int, r.Goroutines)
+}
+
+func (c *queueChecker) op(g int, op workload.Op, _ *rand.Rand) (Record, error) {
+	switch op {
+	case workload.Insert:
+		item := c.base + g + c.pushed[g]*c.stride
+		c.pushed[g]++
+		c.q.Insert(item)
+
+		return Record{Op: op, Value: item}, nil
+	case workload.Lookup:
+		return Record{Op: op, Ok: c.q.Lookup(0)}, nil
+	default:
+		item, ok := c.q.Remove(0)
+		return Record{Op: op, Result: item, Ok: ok}, nil
+	}
+}
+
+func (c *queueChecker) check(r Round, logs [][]Record) error {
+	// every item pushed during the round is accounted for before looking
+	// at what was popped, it may have been popped by any goroutine
+	most := 0
+	for g, log := range logs {
+		for _, rec := range log {
+			if rec.Op == workload.Insert {
+				c.items[rec.Value] = origin{round: r.Number, goroutine: g}
+			}
+		}
+
+		if c.pushed[g] > most {
+			most = c.pushed[g]
+		}
+	}
+	c.base += (most + 1) * c.stride
+
+	for g, log := range logs {
+		var popped []int
+		for _, rec := range log {
+			if rec.Op == workload.Remove && rec.Ok {
+				popped = append(popped, rec.Result)
+			}
+		}
+
+		if _, err := c.take(popped); err != nil {
+			return fmt.Errorf("goroutine %v: %w", g, err)
+		}
+	}
+
+	if c.lener != nil {
+		if got := c.lener.Len(); got != int64(len(c.items)) {
+			return fmt.Errorf("got length %v, want %v", got, len(c.items))
+		}
+	}
+
+	var drained []int
+	for {
+		item, ok := c.q.Remove(0)
+		if !ok {
+			break
+		}
+		drained = append(drained, item)
+	}
+
+	// keep what the queue should hold to report lost items
+	want := len(c.items)
+	origins, err := c.take(drained)
+	if err != nil {
+		return fmt.Errorf("draining: %w", err)
+	}
+
+	if len(c.items) > 0 {
+		return fmt.Errorf("lost %v of %v items, like %v", len(c.items), want, c.anyItem())
+	}
+
+	if len(drained) <= maxRefill {
+		for i, item := range drained {
+			c.q.Insert(item)
+			c.items[item] = origins[i]
+		}
+	}
+
+	return nil
+}
+
+// take removes items popped in that order by a single goroutine from the
+// queue's content, checking that every one was in the queue and that the
+// items of every producer came out in the order they went in. It returns
+// where the items came from.
+func (c *queueChecker) take(items []int) ([]origin, error) {
+	origins := make([]origin, len(items))
+	last := make(map[origin]int)
+
+	for i, item := range items {
+		o, ok := c.items[item]
+		if !ok {
+			return nil, fmt.Errorf("popped %v which is not in the queue, it was popped twice or never pushed", item)
+		}
+
+		if prev, ok := last[o]; ok && item < prev {
+			return nil, fmt.Errorf("popped %v after %v, both pushed in the other order by %v", item, prev, o)
+		}
+
+		last[o] = item
+		origins[i] = o
+		delete(c.items, item)
+	}
+
+	return origins, nil
+}
+
+// anyItem describes one of the items of the queue.
+func (c *queueChecker) anyItem() string {
+	for item, o := range c.items {
+		return fmt.Sprintf("%v pushed by %v", item, o)
+	}
+
+	return "none"
+}
@@ -0,0 +1,309 @@
+// Package stress runs a structure for a long time under randomized
+// workloads and checks its invariants along the way.
+//
+// A stress test is a sequence of rounds. Every round draws from the seed a
+// number of goroutines, a mix of operations and how many operations every
+// goroutine makes. Once all the goroutines of a round are done the
+// structure is quiescent and its invariants are checked:
+//
+//   - queues lose no item, return none twice, return the items of every
+//     producer in the order they were pushed and their Len is the number
+//     of items they hold. The check drains the queue to find lost items
+//     and pushes them back afterwards.
+//   - maps hold exactly the keys they should. Every goroutine of a round
+//     owns a share of the keys, so the result of each of its operations
+//     is known and checked right away.
+//
+// A failure carries the seed and the operations of the failed round. The
+// same seed replays the same rounds with the same operations, though not
+// with the same interleaving.
+package stress
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/workload"
+)
+
+// Config describes a stress test.
+type Config struct {
+	// Structure is the name of the queue or map to stress, one of
+	// workload.Structures.
+	Structure string
+
+	// Duration and Rounds stop the test once elapsed or run, the first one
+	// reached stops it. At least one of them must be set.
+	Duration time.Duration
+	Rounds   int
+
+	// Seed seeds every random choice of the test.
+	Seed int64
+
+	// MaxGoroutines and MaxOps bound the number of goroutines of a round
+	// and the number of operations each of them makes.
+	MaxGoroutines int
+	MaxOps        int
+
+	// Keys is the size of the key space of maps.
+	Keys int
+
+	// Progress, if set, is written a line about every ProgressEvery.
+	Progress      io.Writer
+	ProgressEvery time.Duration
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.Duration <= 0 && c.Rounds <= 0:
+		return fmt.Errorf("stress: neither a duration nor a number of rounds")
+	case c.MaxGoroutines < 1:
+		return fmt.Errorf("stress: rounds need at least one goroutine")
+	case c.MaxOps < 1:
+		return fmt.Errorf("stress: goroutines need at least one operation")
+	case c.Keys < 1:
+		return fmt.Errorf("stress: the key space must not be empty")
+	}
+
+	return nil
+}
+
+// Round is the configuration of a round.
+type Round struct {
+	Number     int
+	Goroutines int
+	Mix        workload.Mix
+
+	// Ops and Seeds are the number of operations and the seed of every
+	// goroutine.
+	Ops   []int
+	Seeds []int64
+}
+
+// newRound draws the configuration of a round, maxGoroutines is at least
+// one.
+func newRound(rng *rand.Rand, number, maxGoroutines, maxOps int) Round {
+	r := Round{
+		Number:     number,
+		Goroutines: 1 + rng.Intn(maxGoroutines),
+		Mix: workload.Mix{
+			Insert: rng.Intn(10),
+			Lookup: rng.Intn(10),
+			Remove: rng.Intn(10),
+		},
+	}
+
+	if r.Mix == (workload.Mix{}) {
+		r.Mix.Insert = 1
+	}
+
+	for g := 0; g < r.Goroutines; g++ {
+		r.Ops = append(r.Ops, 1+rng.Intn(maxOps))
+		r.Seeds = append(r.Seeds, rng.Int63())
+	}
+
+	return r
+}
+
+// Record is an operation made by a goroutine. Value is the key or the
+// pushed item, Result and Ok are what the operation returned.
+type Record struct {
+	Op     workload.Op
+	Value  int
+	Result int
+	Ok     bool
+}
+
+func (r Record) String() string {
+	switch r.Op {
+	case workload.Insert:
+		return fmt.Sprintf("insert %d", r.Value)
+	case workload.Lookup:
+		return fmt.Sprintf("lookup %d: %v", r.Value, r.Ok)
+	default:
+		return fmt.Sprintf("remove %d: %d %v", r.Value, r.Result, r.Ok)
+	}
+}
+
+// checker drives a structure during a round and checks its invariants.
+type checker interface {
+	// begin prepares the checker for a round.
+	begin(r Round)
+
+	// op makes an operation on behalf of goroutine g. It returns an error
+	// if the result is already known to be wrong.
+	op(g int, op workload.Op, rng *rand.Rand) (Record, error)
+
+	// check checks the invariants once every goroutine of the round is
+	// done, given their operations.
+	check(r Round, logs [][]Record) error
+}
+
+// Failure is a stress test which broke an invariant.
+type Failure struct {
+	Structure string
+	Seed      int64
+	Round     Round
+	Err       error
+
+	// Logs are the operations every goroutine made during the round.
+	Logs [][]Record
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("stress: %v failed in round %v of seed %v: %v", f.Structure, f.Round.Number, f.Seed, f.Err)
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// Dump writes the failure along with the last tail operations of every
+// goroutine, or all of them if tail is zero.
+func (f *Failure) Dump(w io.Writer, tail int) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "structure: %v\n", f.Structure)
+	fmt.Fprintf(&b, "seed: %v\n", f.Seed)
+	fmt.Fprintf(&b, "round: %v (goroutines %v, mix %v)\n", f.Round.Number, f.Round.Goroutines, f.Round.Mix)
+	fmt.Fprintf(&b, "error: %v\n", f.Err)
+
+	for g, log := range f.Logs {
+		start := 0
+		if tail > 0 && len(log) > tail {
+			start = len(log) - tail
+		}
+
+		fmt.Fprintf(&b, "goroutine %v: %v of %v operations, seed %v\n", g, len(log), f.Round.Ops[g], f.Round.Seeds[g])
+		for i := start; i < len(log); i++ {
+			fmt.Fprintf(&b, "  #%d %v\n", i, log[i])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Summary tells how much a stress test did.
+type Summary struct {
+	Rounds  int
+	Ops     int64
+	Elapsed time.Duration
+}
+
+// Run runs a stress test and returns a *Failure if an invariant broke.
+func Run(cfg Config) (Summary, error) {
+	if err := cfg.validate(); err != nil {
+		return Summary{}, err
+	}
+
+	target, err := workload.New(cfg.Structure, cfg.MaxGoroutines)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var c checker
+	switch group, _, _ := strings.Cut(cfg.Structure, "/"); group {
+	case "queue":
+		c = newQueueChecker(target)
+	case "hashmap", "ctrie", "skiplist":
+		c = newMapChecker(target, cfg.Keys)
+
+		// every goroutine owns at least a key
+		if cfg.MaxGoroutines > cfg.Keys {
+			cfg.MaxGoroutines = cfg.Keys
+		}
+	default:
+		return Summary{}, fmt.Errorf("stress: %v is neither a queue nor a map", cfg.Structure)
+	}
+
+	return run(cfg, c)
+}
+
+func run(cfg Config, c checker) (Summary, error) {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	var sum Summary
+	start := time.Now()
+	lastProgress := start
+
+	for number := 1; ; number++ {
+		sum.Elapsed = time.Since(start)
+		if (cfg.Rounds > 0 && number > cfg.Rounds) || (cfg.Duration > 0 && sum.Elapsed >= cfg.Duration) {
+			return sum, nil
+		}
+
+		r := newRound(rng, number, cfg.MaxGoroutines, cfg.MaxOps)
+		logs, err := runRound(c, r)
+		if err == nil {
+			err = c.check(r, logs)
+		}
+
+		if err != nil {
+			return sum, &Failure{
+				Structure: cfg.Structure,
+				Seed:      cfg.Seed,
+				Round:     r,
+				Err:       err,
+				Logs:      logs,
+			}
+		}
+
+		sum.Rounds++
+		for _, log := range logs {
+			sum.Ops += int64(len(log))
+		}
+
+		if cfg.Progress != nil && time.Since(lastProgress) >= cfg.ProgressEvery {
+			lastProgress = time.Now()
+			fmt.Fprintf(cfg.Progress, "%v: %v rounds, %v operations\n", time.Since(start).Round(time.Second), sum.Rounds, sum.Ops)
+		}
+	}
+}
+
+// runRound runs the goroutines of a round, they all stop as soon as one of
+// them finds a wrong result.
+func runRound(c checker, r Round) ([][]Record, error) {
+	c.begin(r)
+
+	logs := make([][]Record, r.Goroutines)
+	errs := make([]error, r.Goroutines)
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for g := 0; g < r.Goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(r.Seeds[g]))
+			log := make([]Record, 0, r.Ops[g])
+			for i := 0; i < r.Ops[g] && !stop.Load(); i++ {
+				rec, err := c.op(g, r.Mix.Pick(rng), rng)
+				log = append(log, rec)
+
+				if err != nil {
+					errs[g] = fmt.Errorf("goroutine %v, operation #%v: %w", g, i, err)
+					stop.Store(true)
+					break
+				}
+			}
+
+			logs[g] = log
+		}(g)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return logs, err
+		}
+	}
+
+	return logs, nil
+}
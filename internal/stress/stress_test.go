@@ -0,0 +1,235 @@
+package stress
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/workload"
+)
+
+func config(structure string) Config {
+	return Config{
+		Structure:     structure,
+		Rounds:        20,
+		Seed:          1,
+		MaxGoroutines: 4,
+		MaxOps:        500,
+		Keys:          64,
+	}
+}
+
+func Test_Run(t *testing.T) {
+	for _, name := range workload.Structures() {
+		group, _, _ := strings.Cut(name, "/")
+		if group != "queue" && group != "hashmap" && group != "ctrie" && group != "skiplist" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			sum, err := Run(config(name))
+			if err != nil {
+				t.Fatalf("got %v, want %v", err, nil)
+			}
+
+			if sum.Rounds != 20 || sum.Ops == 0 {
+				t.Errorf("got %+v, want 20 rounds", sum)
+			}
+		})
+	}
+}
+
+func Test_Run_Config(t *testing.T) {
+	if _, err := Run(config("lock/tas")); err == nil {
+		t.Errorf("stressing a lock did not fail")
+	}
+
+	if _, err := Run(config("nope")); err == nil {
+		t.Errorf("stressing an unknown structure did not fail")
+	}
+
+	cfg := config("queue")
+	cfg.Rounds = 0
+	if _, err := Run(cfg); err == nil {
+		t.Errorf("running forever did not fail")
+	}
+
+	cfg.Duration = 20 * time.Millisecond
+	if sum, err := Run(cfg); err != nil || sum.Elapsed < cfg.Duration {
+		t.Errorf("got %+v and %v, want to run for %v", sum, err, cfg.Duration)
+	}
+}
+
+func Test_NewRound(t *testing.T) {
+	rounds := func(seed int64) []Round {
+		rng := rand.New(rand.NewSource(seed))
+
+		var rounds []Round
+		for i := 1; i <= 10; i++ {
+			rounds = append(rounds, newRound(rng, i, 8, 100))
+		}
+
+		return rounds
+	}
+
+	a, b := rounds(1), rounds(1)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("the same seed gave different rounds")
+	}
+
+	for _, r := range a {
+		if r.Goroutines < 1 || r.Goroutines > 8 || len(r.Ops) != r.Goroutines {
+			t.Errorf("got %+v, want 1 to 8 goroutines", r)
+		}
+	}
+}
+
+// buggyQueue is a queue behind a mutex which breaks in a chosen way.
+type buggyQueue struct {
+	mu    sync.Mutex
+	items []int
+	n     int
+
+	// drop drops every 50th item, dup pops every 50th item twice, lifo
+	// pops the newest item and len miscounts
+	bug string
+}
+
+func (q *buggyQueue) Insert(key int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.n++
+	if q.bug == "drop" && q.n%50 == 0 {
+		return
+	}
+
+	q.items = append(q.items, key)
+}
+
+func (q *buggyQueue) Lookup(int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.items) > 0
+}
+
+func (q *buggyQueue) Remove(int) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return 0, false
+	}
+
+	q.n++
+	if q.bug == "lifo" {
+		item := q.items[len(q.items)-1]
+		q.items = q.items[:len(q.items)-1]
+		return item, true
+	}
+
+	item := q.items[0]
+	if q.bug != "dup" || q.n%50 != 0 {
+		q.items = q.items[1:]
+	}
+
+	return item, true
+}
+
+func (q *buggyQueue) Len() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.bug == "len" {
+		return int64(len(q.items)) + 1
+	}
+
+	return int64(len(q.items))
+}
+
+// forgetfulMap is a map behind a mutex which forgets every 50th insert.
+type forgetfulMap struct {
+	mu   sync.Mutex
+	keys map[int]bool
+	n    int
+}
+
+func (m *forgetfulMap) Insert(key int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.n++
+	if m.n%50 != 0 {
+		m.keys[key] = true
+	}
+}
+
+func (m *forgetfulMap) Lookup(key int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.keys[key]
+}
+
+func (m *forgetfulMap) Remove(key int) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := m.keys[key]
+	delete(m.keys, key)
+	return key, ok
+}
+
+func Test_Run_Failure(t *testing.T) {
+	checkers := map[string]func() checker{
+		"queue - drop": func() checker {
+			return newQueueChecker(&buggyQueue{bug: "drop"})
+		},
+		"queue - dup": func() checker {
+			return newQueueChecker(&buggyQueue{bug: "dup"})
+		},
+		"queue - lifo": func() checker {
+			return newQueueChecker(&buggyQueue{bug: "lifo"})
+		},
+		"queue - len": func() checker {
+			return newQueueChecker(&buggyQueue{bug: "len"})
+		},
+		"map - forgetful": func() checker {
+			return newMapChecker(&forgetfulMap{keys: make(map[int]bool)}, 64)
+		},
+	}
+
+	for name, newChecker := range checkers {
+		t.Run(name, func(t *testing.T) {
+			cfg := config(name)
+			_, err := run(cfg, newChecker())
+
+			var failure *Failure
+			if !errors.As(err, &failure) {
+				t.Fatalf("got %v, want a failure", err)
+			}
+
+			var dump strings.Builder
+			if err := failure.Dump(&dump, 5); err != nil {
+				t.Fatalf("got %v, want %v", err, nil)
+			}
+
+			for _, want := range []string{"seed: 1", "error: ", "goroutine 0: "} {
+				if !strings.Contains(dump.String(), want) {
+					t.Errorf("got %q, want it to contain %q", dump.String(), want)
+				}
+			}
+
+			// the same seed fails again, though maybe in another round
+			_, again := run(cfg, newChecker())
+			if !errors.As(again, &failure) {
+				t.Fatalf("got %v, want a failure", again)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"math/bits"
 	"math/rand"
 	"strings"
 )
@@ -16,39 +17,161 @@ func GenerateIntSeries(start, end int) []int {
 	return series
 }
 
+// Seed seeds the generators which are not given a seed, it is fixed so
+// that failing tests can be reproduced.
+const Seed int64 = 1
+
+// NewRand returns a generator seeded with seed, which is not safe for
+// concurrent use.
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
 // GenerateRandomIntSeries generates a slice of integers from start to end
-// where end is inclusive and the slice is shuffled.
+// where end is inclusive and the slice is shuffled with Seed.
 func GenerateRandomIntSeries(start, end int) []int {
+	return GenerateSeededIntSeries(Seed, start, end)
+}
+
+// GenerateSeededIntSeries generates a slice of integers from start to end
+// where end is inclusive and the slice is shuffled, the same seed always
+// gives the same order.
+func GenerateSeededIntSeries(seed int64, start, end int) []int {
 	series := GenerateIntSeries(start, end)
+	rng := NewRand(seed)
 
-	rand.Shuffle(end-start+1, func(i, j int) {
+	// Fisher-Yates
+	for i := len(series) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
 		series[i], series[j] = series[j], series[i]
-	})
+	}
 
 	return series
 }
 
+// GenerateSeededInts generates n integers in [0, max), the same seed always
+// gives the same integers.
+func GenerateSeededInts(seed int64, n, max int) []int {
+	rng := NewRand(seed)
+
+	ints := make([]int, n)
+	for i := range ints {
+		ints[i] = rng.Intn(max)
+	}
+
+	return ints
+}
+
 // CompareSliceUnordered compares two slices of comparable types
-// and returns true if they contain the same elements.
+// and returns true if they contain the same elements the same number of
+// times.
 func CompareSliceUnordered[T comparable](a, b []T) bool {
 	if len(a) != len(b) {
 		return false
 	}
 
-	mpa := make(map[T]struct{}, len(a))
+	counts := make(map[T]int, len(a))
 	for _, v := range a {
-		mpa[v] = struct{}{}
+		counts[v]++
 	}
 
 	for _, v := range b {
-		if _, ok := mpa[v]; !ok {
+		if counts[v] == 0 {
 			return false
 		}
+		counts[v]--
 	}
 
 	return true
 }
 
+// ProducerItem is an item tagged with the producer which made it and its
+// position among the items of that producer, starting at 0.
+type ProducerItem struct {
+	Producer int
+	Seq      int
+}
+
+// GenerateProducerItems generates the items of every producer in the order
+// they make them.
+func GenerateProducerItems(producers, items int) [][]ProducerItem {
+	all := make([][]ProducerItem, producers)
+	for p := range all {
+		all[p] = make([]ProducerItem, items)
+		for s := range all[p] {
+			all[p][s] = ProducerItem{Producer: p, Seq: s}
+		}
+	}
+
+	return all
+}
+
+// VerifyProducerOrder checks that the items of every producer were consumed
+// in the order they were produced. Items may be missing, as when several
+// consumers share a queue and this is one of them.
+func VerifyProducerOrder(consumed []ProducerItem) error {
+	last := make(map[int]int)
+	for i, item := range consumed {
+		if prev, ok := last[item.Producer]; ok && item.Seq <= prev {
+			return fmt.Errorf(
+				"item %v of producer %v consumed at %v after item %v",
+				item.Seq, item.Producer, i, prev,
+			)
+		}
+		last[item.Producer] = item.Seq
+	}
+
+	return nil
+}
+
+// VerifyProducerFIFO checks the items taken by every consumer of a FIFO
+// queue which producers each filled with items generated by
+// GenerateProducerItems: every consumer saw the items of a producer in
+// order and every item was consumed exactly once.
+func VerifyProducerFIFO(consumers [][]ProducerItem, producers, items int) error {
+	seen := make(map[ProducerItem]bool, producers*items)
+
+	for c, consumed := range consumers {
+		if err := VerifyProducerOrder(consumed); err != nil {
+			return fmt.Errorf("consumer %v: %w", c, err)
+		}
+
+		for _, item := range consumed {
+			if item.Producer < 0 || item.Producer >= producers || item.Seq < 0 || item.Seq >= items {
+				return fmt.Errorf("consumer %v: unexpected item %+v", c, item)
+			}
+
+			if seen[item] {
+				return fmt.Errorf("consumer %v: item %+v consumed twice", c, item)
+			}
+			seen[item] = true
+		}
+	}
+
+	if len(seen) != producers*items {
+		return fmt.Errorf("got %v items, want %v", len(seen), producers*items)
+	}
+
+	return nil
+}
+
+// TreeWidth returns a width for the counting trees and networks shared by
+// the given number of goroutines, or by procs if that is more, usually
+// GOMAXPROCS. It is the next power of two and at least 2, so a combining
+// tree, which has half as many leaves as its width, gets the two callers
+// per leaf it works best with.
+func TreeWidth(goroutines, procs int) int {
+	if goroutines < procs {
+		goroutines = procs
+	}
+
+	if goroutines < 2 {
+		return 2
+	}
+
+	return 1 << bits.Len(uint(goroutines-1))
+}
+
 // Assert panics if the condition is false.
 func Assert(cond bool, msg ...string) {
 	if !cond {
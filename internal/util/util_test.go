@@ -0,0 +1,156 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_CompareSliceUnordered(t *testing.T) {
+	type test struct {
+		name string
+		a, b []int
+		want bool
+	}
+
+	tests := []test{
+		{name: "empty", a: []int{}, b: nil, want: true},
+		{name: "same order", a: []int{1, 2, 3}, b: []int{1, 2, 3}, want: true},
+		{name: "different order", a: []int{1, 2, 3}, b: []int{3, 1, 2}, want: true},
+		{name: "same duplicates", a: []int{1, 1, 2}, b: []int{1, 2, 1}, want: true},
+		{name: "different duplicates", a: []int{1, 1, 2}, b: []int{1, 2, 2}, want: false},
+		{name: "different lengths", a: []int{1, 2}, b: []int{1, 2, 2}, want: false},
+		{name: "different elements", a: []int{1, 2}, b: []int{1, 3}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareSliceUnordered(tt.a, tt.b); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			if got := CompareSliceUnordered(tt.b, tt.a); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GenerateSeededIntSeries(t *testing.T) {
+	a := GenerateSeededIntSeries(1, 1, 100)
+	b := GenerateSeededIntSeries(1, 1, 100)
+
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("got %v, want %v", b, a)
+	}
+
+	if !CompareSliceUnordered(a, GenerateIntSeries(1, 100)) {
+		t.Errorf("got %v, want a permutation of 1 to 100", a)
+	}
+
+	if reflect.DeepEqual(a, GenerateSeededIntSeries(2, 1, 100)) {
+		t.Errorf("different seeds gave the same order %v", a)
+	}
+
+	if got := GenerateSeededIntSeries(1, 1, 0); len(got) != 0 {
+		t.Errorf("got %v, want %v", got, []int{})
+	}
+
+	ints := GenerateSeededInts(1, 100, 10)
+	if !reflect.DeepEqual(ints, GenerateSeededInts(1, 100, 10)) {
+		t.Errorf("same seed gave different integers")
+	}
+
+	for _, v := range ints {
+		if v < 0 || v >= 10 {
+			t.Errorf("got %v, want an integer in [0, 10)", v)
+		}
+	}
+}
+
+func Test_VerifyProducerFIFO(t *testing.T) {
+	item := func(p, s int) ProducerItem {
+		return ProducerItem{Producer: p, Seq: s}
+	}
+
+	type test struct {
+		name      string
+		consumers [][]ProducerItem
+		wantErr   bool
+	}
+
+	tests := []test{
+		{
+			name: "single consumer",
+			consumers: [][]ProducerItem{
+				{item(0, 0), item(1, 0), item(0, 1), item(1, 1)},
+			},
+		},
+		{
+			name: "several consumers",
+			consumers: [][]ProducerItem{
+				{item(0, 0), item(1, 1)},
+				{item(1, 0), item(0, 1)},
+			},
+		},
+		{
+			name: "out of order",
+			consumers: [][]ProducerItem{
+				{item(0, 1), item(0, 0), item(1, 0), item(1, 1)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated",
+			consumers: [][]ProducerItem{
+				{item(0, 0), item(0, 1), item(1, 0), item(1, 1)},
+				{item(1, 1)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lost",
+			consumers: [][]ProducerItem{
+				{item(0, 0), item(0, 1), item(1, 1)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unexpected",
+			consumers: [][]ProducerItem{
+				{item(0, 0), item(0, 1), item(1, 0), item(1, 1), item(2, 0)},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyProducerFIFO(tt.consumers, 2, 2)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	produced := GenerateProducerItems(2, 2)
+	if err := VerifyProducerFIFO(produced, 2, 2); err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+}
+
+func Test_TreeWidth(t *testing.T) {
+	tests := map[int]int{1: 2, 2: 2, 3: 4, 4: 4, 5: 8, 16: 16, 17: 32}
+	for goroutines, want := range tests {
+		if got := TreeWidth(goroutines, 1); got != want {
+			t.Errorf("%v goroutines: got %v, want %v", goroutines, got, want)
+		}
+	}
+
+	if got := TreeWidth(1, 4); got != 4 {
+		t.Errorf("got %v, want %v", got, 4)
+	}
+
+	if got := TreeWidth(8, 4); got != 8 {
+		t.Errorf("got %v, want %v", got, 8)
+	}
+}
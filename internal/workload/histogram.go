@@ -0,0 +1,97 @@
+package workload
+
+import (
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of a histogram, latencies are exact
+// below 2^subBucketBits nanoseconds and above that their buckets are
+// less than 1/2^subBucketBits of their value wide.
+const subBucketBits = 4
+
+const (
+	subBuckets = 1 << subBucketBits
+
+	// durations are below 2^63, the last subBuckets cover [2^62, 2^63)
+	buckets = (64 - subBucketBits) * subBuckets
+)
+
+// histogram counts latencies in log-linear buckets, recording one costs a
+// few instructions and no allocation. It is not safe for concurrent use,
+// every goroutine records its own and they are merged once it is done.
+type histogram struct {
+	counts [buckets]uint64
+	total  uint64
+	max    time.Duration
+}
+
+func bucketOf(d time.Duration) int {
+	v := uint64(d)
+	if d < 0 {
+		v = 0
+	}
+
+	if v < subBuckets {
+		return int(v)
+	}
+
+	shift := bits.Len64(v) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(v>>shift) - subBuckets
+}
+
+// bucketMax returns the greatest latency counted in a bucket.
+func bucketMax(i int) time.Duration {
+	if i < subBuckets {
+		return time.Duration(i)
+	}
+
+	shift := i/subBuckets - 1
+	mantissa := uint64(i%subBuckets + subBuckets)
+	return time.Duration((mantissa+1)<<shift - 1)
+}
+
+func (h *histogram) record(d time.Duration) {
+	h.counts[bucketOf(d)]++
+	h.total++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+
+	h.total += other.total
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// percentile returns the latency below which p percent of the recorded
+// latencies fall, rounded up to the end of its bucket.
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(p / 100 * float64(h.total))
+	if rank >= h.total {
+		return h.max
+	}
+
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen > rank {
+			if d := bucketMax(i); d < h.max {
+				return d
+			}
+			return h.max
+		}
+	}
+
+	return h.max
+}
@@ -0,0 +1,51 @@
+package workload
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable writes results as a table aligned for terminals.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "structure\tgoroutines\tmix\tops\tops/s\tp50\tp90\tp99\tp99.9\tmax\tmisses\tallocs/op\tB/op\t")
+	for _, r := range results {
+		mix := r.Mix
+		if mix == "" {
+			mix = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%.0f\t%v\t%v\t%v\t%v\t%v\t%d\t%.2f\t%.1f\t\n",
+			r.Structure, r.Goroutines, mix, r.Ops, r.Throughput,
+			round(r.Latency.P50), round(r.Latency.P90), round(r.Latency.P99),
+			round(r.Latency.P999), round(r.Latency.Max),
+			r.Misses, r.AllocsPerOp, r.BytesPerOp,
+		)
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON writes results as an indented JSON array.
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(results)
+}
+
+// round keeps three significant digits of a latency so that columns stay
+// readable.
+func round(d time.Duration) time.Duration {
+	for unit := time.Duration(1); unit < time.Hour; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit)
+		}
+	}
+
+	return d.Round(time.Second)
+}
@@ -0,0 +1,448 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/util"
+	"github.com/tangledbytes/godc/pkg/counter"
+	"github.com/tangledbytes/godc/pkg/countingnetwork"
+	"github.com/tangledbytes/godc/pkg/ctrie"
+	"github.com/tangledbytes/godc/pkg/hashmap"
+	"github.com/tangledbytes/godc/pkg/locks"
+	"github.com/tangledbytes/godc/pkg/queue"
+	"github.com/tangledbytes/godc/pkg/rwlock"
+	"github.com/tangledbytes/godc/pkg/semaphore"
+	"github.com/tangledbytes/godc/pkg/skiplist"
+)
+
+// Target is a structure under load. Its methods are called concurrently
+// with keys drawn from the key space of the workload.
+type Target interface {
+	// Insert adds the key, Push for queues and Store for maps.
+	Insert(key int)
+
+	// Lookup reports whether the key, or for queues any item, is present.
+	Lookup(key int) bool
+
+	// Remove removes the key, or for queues the oldest item, and returns
+	// it if there was one.
+	Remove(key int) (int, bool)
+}
+
+// Lener is implemented by targets which can report their size.
+type Lener interface {
+	Len() int64
+}
+
+// newTarget builds a target for a workload run by the given number of
+// goroutines.
+type newTarget func(goroutines int) Target
+
+// targets returns every structure of the library by name, grouped by the
+// prefix before the slash.
+func targets() map[string]newTarget {
+	t := map[string]newTarget{
+		"queue": func(int) Target {
+			return queueTarget{queue.New[int]()}
+		},
+		"queue/striped": func(int) Target {
+			return queueTarget{queue.New[int](queue.WithStripedLen())}
+		},
+		"queue/recycled": func(int) Target {
+			return queueTarget{queue.New[int](queue.WithRecycledNodes())}
+		},
+		"hashmap/sync.Map": func(int) Target {
+			return syncMapTarget{&sync.Map{}}
+		},
+		"hashmap/split-ordered": func(int) Target {
+			return mapTarget{hashmap.NewSplitOrdered[int, int]()}
+		},
+		"hashmap/striped": func(int) Target {
+			return mapTarget{hashmap.NewStriped[int, int]()}
+		},
+		"hashmap/refinable": func(int) Target {
+			return mapTarget{hashmap.NewRefinable[int, int]()}
+		},
+		"hashmap/cuckoo": func(int) Target {
+			return mapTarget{hashmap.NewCuckoo[int, int]()}
+		},
+		"hashmap/hopscotch": func(int) Target {
+			return mapTarget{hashmap.NewHopscotch[int, int]()}
+		},
+		"ctrie": func(int) Target {
+			return ctrieTarget{ctrie.New[int, int]()}
+		},
+		"skiplist/lock-free": func(int) Target {
+			return skiplistTarget{skiplist.NewLockFree[int, int]()}
+		},
+		"skiplist/lazy": func(int) Target {
+			return skiplistTarget{skiplist.NewLazy[int, int]()}
+		},
+		"counter/striped": func(int) Target {
+			return stripedTarget{counter.NewStriped()}
+		},
+		"counter/combining-tree": func(goroutines int) Target {
+			return counterTarget{counter.NewCombiningTree(util.TreeWidth(goroutines, runtime.GOMAXPROCS(0)))}
+		},
+		"counter/diffracting-tree": func(goroutines int) Target {
+			return counterTarget{counter.NewDiffractingTree(util.TreeWidth(goroutines, runtime.GOMAXPROCS(0)))}
+		},
+		"counter/bitonic": func(goroutines int) Target {
+			network := countingnetwork.NewBitonic(util.TreeWidth(goroutines, runtime.GOMAXPROCS(0)))
+			return counterTarget{countingnetwork.NewCounter(network)}
+		},
+		"counter/periodic": func(goroutines int) Target {
+			network := countingnetwork.NewPeriodic(util.TreeWidth(goroutines, runtime.GOMAXPROCS(0)))
+			return counterTarget{countingnetwork.NewCounter(network)}
+		},
+		"rwlock/sync.RWMutex": func(int) Target {
+			return &rwLockTarget{l: &sync.RWMutex{}}
+		},
+		"rwlock/reader-preference": func(int) Target {
+			return &rwLockTarget{l: rwlock.NewReaderPref()}
+		},
+		"rwlock/writer-preference": func(int) Target {
+			return &rwLockTarget{l: rwlock.NewWriterPref()}
+		},
+		"rwlock/fair": func(int) Target {
+			return &rwLockTarget{l: rwlock.NewFair()}
+		},
+		"rwlock/bravo": func(int) Target {
+			return &rwLockTarget{l: rwlock.NewBravo()}
+		},
+		"semaphore": func(goroutines int) Target {
+			// half the goroutines fit, the others wait
+			return semaphoreTarget{semaphore.New(int64(goroutines+1) / 2)}
+		},
+	}
+
+	lockers := map[string]func(goroutines int) sync.Locker{
+		"sync.Mutex": func(int) sync.Locker {
+			return &sync.Mutex{}
+		},
+		"tas": func(int) sync.Locker {
+			return &locks.TAS{}
+		},
+		"ttas": func(int) sync.Locker {
+			return &locks.TTAS{}
+		},
+		"backoff": func(int) sync.Locker {
+			return locks.NewBackoff(time.Microsecond, 100*time.Microsecond)
+		},
+		"anderson": func(goroutines int) sync.Locker {
+			return locks.NewAnderson(goroutines)
+		},
+		"clh": func(int) sync.Locker {
+			return &locks.CLH{}
+		},
+		"mcs": func(int) sync.Locker {
+			return &locks.MCS{}
+		},
+		"to": func(int) sync.Locker {
+			return &locks.TOLock{}
+		},
+		"composite": func(int) sync.Locker {
+			return locks.NewComposite(4, time.Microsecond, 100*time.Microsecond)
+		},
+		"cohort": func(int) sync.Locker {
+			return locks.NewCohort(4, nil)
+		},
+		"reentrant": func(int) sync.Locker {
+			return &locks.ReentrantLock{}
+		},
+	}
+
+	for name, newLock := range lockers {
+		newLock := newLock
+		t["lock/"+name] = func(goroutines int) Target {
+			return &lockTarget{l: newLock(goroutines)}
+		}
+	}
+
+	return t
+}
+
+// Structures returns the names of the structures workloads can run
+// against, sorted.
+func Structures() []string {
+	var names []string
+	for name := range targets() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns a structure by name, sized for a workload run by the given
+// number of goroutines.
+func New(name string, goroutines int) (Target, error) {
+	newTarget, ok := targets()[name]
+	if !ok {
+		return nil, fmt.Errorf("workload: unknown structure %q", name)
+	}
+
+	return newTarget(goroutines), nil
+}
+
+// Select expands a comma separated list of structure names into the
+// structures it names. A group name, the part of a name before the slash,
+// selects every structure of the group and "all" selects everything.
+func Select(list string) ([]string, error) {
+	all := Structures()
+
+	var selected []string
+	seen := make(map[string]bool)
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.TrimSpace(pattern)
+
+		found := false
+		for _, name := range all {
+			if pattern == "all" || name == pattern || strings.HasPrefix(name, pattern+"/") {
+				found = true
+				if !seen[name] {
+					seen[name] = true
+					selected = append(selected, name)
+				}
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("workload: unknown structure %q", pattern)
+		}
+	}
+
+	return selected, nil
+}
+
+type queueTarget struct {
+	q *queue.Queue[int]
+}
+
+func (t queueTarget) Insert(key int) {
+	t.q.Push(key)
+}
+
+func (t queueTarget) Lookup(int) bool {
+	_, ok := t.q.Peek()
+	return ok
+}
+
+func (t queueTarget) Remove(int) (int, bool) {
+	return t.q.Pop()
+}
+
+func (t queueTarget) Len() int64 {
+	return t.q.Len()
+}
+
+// mapTarget runs against a hash map. Maps cannot tell what Delete removed
+// so Remove loads the key first, which makes it two operations.
+type mapTarget struct {
+	m interface {
+		hashmap.Map[int, int]
+		Len() int
+	}
+}
+
+func (t mapTarget) Insert(key int) {
+	t.m.Store(key, key)
+}
+
+func (t mapTarget) Lookup(key int) bool {
+	_, ok := t.m.Load(key)
+	return ok
+}
+
+func (t mapTarget) Remove(key int) (int, bool) {
+	value, ok := t.m.Load(key)
+	t.m.Delete(key)
+	return value, ok
+}
+
+func (t mapTarget) Len() int64 {
+	return int64(t.m.Len())
+}
+
+// syncMapTarget is the baseline for the maps.
+type syncMapTarget struct {
+	m *sync.Map
+}
+
+func (t syncMapTarget) Insert(key int) {
+	t.m.Store(key, key)
+}
+
+func (t syncMapTarget) Lookup(key int) bool {
+	_, ok := t.m.Load(key)
+	return ok
+}
+
+func (t syncMapTarget) Remove(key int) (int, bool) {
+	value, ok := t.m.LoadAndDelete(key)
+	if !ok {
+		return 0, false
+	}
+
+	return value.(int), true
+}
+
+type ctrieTarget struct {
+	c *ctrie.Ctrie[int, int]
+}
+
+func (t ctrieTarget) Insert(key int) {
+	t.c.Store(key, key)
+}
+
+func (t ctrieTarget) Lookup(key int) bool {
+	_, ok := t.c.Load(key)
+	return ok
+}
+
+func (t ctrieTarget) Remove(key int) (int, bool) {
+	return t.c.LoadAndDelete(key)
+}
+
+func (t ctrieTarget) Len() int64 {
+	return int64(t.c.Len())
+}
+
+type skiplistTarget struct {
+	s interface {
+		skiplist.Map[int, int]
+		Len() int
+	}
+}
+
+func (t skiplistTarget) Insert(key int) {
+	t.s.Put(key, key)
+}
+
+func (t skiplistTarget) Lookup(key int) bool {
+	return t.s.Contains(key)
+}
+
+func (t skiplistTarget) Remove(key int) (int, bool) {
+	return t.s.Delete(key)
+}
+
+func (t skiplistTarget) Len() int64 {
+	return int64(t.s.Len())
+}
+
+// stripedTarget adds one on Insert and takes one on Remove, Lookup sums
+// the stripes.
+type stripedTarget struct {
+	c *counter.Striped
+}
+
+func (t stripedTarget) Insert(int) {
+	t.c.Add(1)
+}
+
+func (t stripedTarget) Lookup(int) bool {
+	return t.c.Sum() > 0
+}
+
+func (t stripedTarget) Remove(int) (int, bool) {
+	t.c.Add(-1)
+	return 0, true
+}
+
+// counterTarget increments the counter on every operation, these
+// counters cannot be read without incrementing them.
+type counterTarget struct {
+	c interface {
+		GetAndIncrement() int64
+	}
+}
+
+func (t counterTarget) Insert(int) {
+	t.c.GetAndIncrement()
+}
+
+func (t counterTarget) Lookup(int) bool {
+	t.c.GetAndIncrement()
+	return true
+}
+
+func (t counterTarget) Remove(int) (int, bool) {
+	return int(t.c.GetAndIncrement()), true
+}
+
+// lockTarget increments a counter under the lock on every operation.
+type lockTarget struct {
+	l sync.Locker
+	n int
+}
+
+func (t *lockTarget) Insert(int) {
+	t.l.Lock()
+	t.n++
+	t.l.Unlock()
+}
+
+func (t *lockTarget) Lookup(key int) bool {
+	t.Insert(key)
+	return true
+}
+
+func (t *lockTarget) Remove(key int) (int, bool) {
+	t.Insert(key)
+	return 0, true
+}
+
+// rwLockTarget reads a counter under the read lock on Lookup, Insert and
+// Remove increment it under the write lock.
+type rwLockTarget struct {
+	l rwlock.RWLocker
+	n int
+}
+
+func (t *rwLockTarget) Insert(int) {
+	t.l.Lock()
+	t.n++
+	t.l.Unlock()
+}
+
+func (t *rwLockTarget) Lookup(int) bool {
+	t.l.RLock()
+	n := t.n
+	t.l.RUnlock()
+
+	return n > 0
+}
+
+func (t *rwLockTarget) Remove(key int) (int, bool) {
+	t.Insert(key)
+	return 0, true
+}
+
+// semaphoreTarget acquires and releases a unit on Insert and Remove,
+// Lookup reports whether one is available.
+type semaphoreTarget struct {
+	s *semaphore.Semaphore
+}
+
+func (t semaphoreTarget) Insert(int) {
+	if err := t.s.Acquire(context.Background(), 1); err != nil {
+		panic(err)
+	}
+	t.s.Release(1)
+}
+
+func (t semaphoreTarget) Lookup(int) bool {
+	return t.s.Available() > 0
+}
+
+func (t semaphoreTarget) Remove(key int) (int, bool) {
+	t.Insert(key)
+	return 0, true
+}
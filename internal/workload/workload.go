@@ -0,0 +1,320 @@
+// Package workload runs configurable workloads against the structures of
+// the library and measures them.
+//
+// A workload is run by three kinds of goroutines: producers which only
+// insert, consumers which only remove and workers which pick every
+// operation at random following a mix of inserts, lookups and removes.
+// Keys are drawn uniformly from a key space, queues ignore them.
+//
+// Every operation is timed on its own, so the overhead of reading the
+// clock, a few tens of nanoseconds, is part of the latencies and lowers
+// the throughput of the fastest structures.
+package workload
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op is an operation of a workload.
+type Op int
+
+const (
+	Insert Op = iota
+	Lookup
+	Remove
+)
+
+func (o Op) String() string {
+	switch o {
+	case Insert:
+		return "insert"
+	case Lookup:
+		return "lookup"
+	case Remove:
+		return "remove"
+	default:
+		return fmt.Sprintf("op(%d)", int(o))
+	}
+}
+
+// Mix is the relative weight of every operation picked by workers.
+type Mix struct {
+	Insert int
+	Lookup int
+	Remove int
+}
+
+// DefaultMix inserts and removes as often, and looks up in between.
+var DefaultMix = Mix{Insert: 25, Lookup: 50, Remove: 25}
+
+// ParseMix parses a mix written as insert:lookup:remove weights, like
+// 25:50:25.
+func ParseMix(s string) (Mix, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return Mix{}, fmt.Errorf("workload: mix %q is not insert:lookup:remove", s)
+	}
+
+	var weights [3]int
+	for i, part := range parts {
+		w, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || w < 0 {
+			return Mix{}, fmt.Errorf("workload: mix %q has an invalid weight %q", s, part)
+		}
+		weights[i] = w
+	}
+
+	m := Mix{Insert: weights[0], Lookup: weights[1], Remove: weights[2]}
+	if m.total() == 0 {
+		return Mix{}, fmt.Errorf("workload: mix %q has no operations", s)
+	}
+
+	return m, nil
+}
+
+func (m Mix) String() string {
+	return fmt.Sprintf("%d:%d:%d", m.Insert, m.Lookup, m.Remove)
+}
+
+func (m Mix) total() int {
+	return m.Insert + m.Lookup + m.Remove
+}
+
+// Pick picks an operation following the mix.
+func (m Mix) Pick(rng *rand.Rand) Op {
+	n := rng.Intn(m.total())
+	switch {
+	case n < m.Insert:
+		return Insert
+	case n < m.Insert+m.Lookup:
+		return Lookup
+	default:
+		return Remove
+	}
+}
+
+// Config describes a workload.
+type Config struct {
+	// Structure is the name of the structure to run against, one of
+	// Structures.
+	Structure string
+
+	// Producers, Consumers and Workers are the number of goroutines of
+	// every kind.
+	Producers int
+	Consumers int
+	Workers   int
+
+	// Mix is the mix of operations of the workers.
+	Mix Mix
+
+	// Duration stops the workload once elapsed, Ops once that many
+	// operations were made. At least one of them must be set, the first
+	// one reached stops the workload.
+	Duration time.Duration
+	Ops      int64
+
+	// Keys is the size of the key space and Prefill the number of keys
+	// inserted before the workload starts.
+	Keys    int
+	Prefill int
+
+	// Seed seeds the random choices of the goroutines.
+	Seed int64
+}
+
+func (c Config) goroutines() int {
+	return c.Producers + c.Consumers + c.Workers
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.Producers < 0 || c.Consumers < 0 || c.Workers < 0:
+		return fmt.Errorf("workload: goroutine counts must not be negative")
+	case c.goroutines() == 0:
+		return fmt.Errorf("workload: no producers, consumers or workers")
+	case c.Workers > 0 && c.Mix.total() <= 0:
+		return fmt.Errorf("workload: workers need a mix")
+	case c.Duration <= 0 && c.Ops <= 0:
+		return fmt.Errorf("workload: neither a duration nor a number of operations")
+	case c.Keys < 1:
+		return fmt.Errorf("workload: the key space must not be empty")
+	case c.Prefill < 0:
+		return fmt.Errorf("workload: prefill must not be negative")
+	}
+
+	return nil
+}
+
+// Latency summarises the latencies of the operations of a workload.
+type Latency struct {
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	P999 time.Duration `json:"p999_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+// Result is the measure of a workload.
+type Result struct {
+	Structure  string        `json:"structure"`
+	Goroutines int           `json:"goroutines"`
+	Mix        string        `json:"mix"`
+	Ops        int64         `json:"ops"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	Throughput float64       `json:"ops_per_sec"`
+	Latency    Latency       `json:"latency"`
+
+	// Misses counts lookups and removes which found nothing.
+	Misses int64 `json:"misses"`
+
+	// AllocsPerOp and BytesPerOp are the heap allocations made while the
+	// workload ran, divided by the number of operations.
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+}
+
+// worker is the state of one goroutine of a workload.
+type worker struct {
+	mix Mix
+	rng *rand.Rand
+
+	// quota is the number of operations to make, -1 for no limit
+	quota  int64
+	ops    int64
+	misses int64
+	hist   histogram
+}
+
+// Run runs a workload and measures it.
+func Run(cfg Config) (Result, error) {
+	if err := cfg.validate(); err != nil {
+		return Result{}, err
+	}
+
+	goroutines := cfg.goroutines()
+	target, err := New(cfg.Structure, goroutines)
+	if err != nil {
+		return Result{}, err
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	for i := 0; i < cfg.Prefill; i++ {
+		target.Insert(rng.Intn(cfg.Keys))
+	}
+
+	workers := make([]*worker, 0, goroutines)
+	add := func(n int, mix Mix) {
+		for i := 0; i < n; i++ {
+			seed := cfg.Seed + int64(len(workers)) + 1
+			workers = append(workers, &worker{
+				mix:   mix,
+				rng:   rand.New(rand.NewSource(seed)),
+				quota: -1,
+			})
+		}
+	}
+	add(cfg.Producers, Mix{Insert: 1})
+	add(cfg.Consumers, Mix{Remove: 1})
+	add(cfg.Workers, cfg.Mix)
+
+	// the operations are shared out evenly, the first goroutines make one
+	// more when they do not divide
+	if cfg.Ops > 0 {
+		for i, w := range workers {
+			w.quota = cfg.Ops / int64(goroutines)
+			if int64(i) < cfg.Ops%int64(goroutines) {
+				w.quota++
+			}
+		}
+	}
+
+	var stop atomic.Bool
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			w.run(target, cfg.Keys, &stop)
+		}(w)
+	}
+
+	if cfg.Duration > 0 {
+		timer := time.AfterFunc(cfg.Duration, func() {
+			stop.Store(true)
+		})
+		defer timer.Stop()
+	}
+
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	var hist histogram
+	res := Result{
+		Structure:  cfg.Structure,
+		Goroutines: goroutines,
+		Elapsed:    elapsed,
+	}
+	if cfg.Workers > 0 {
+		res.Mix = cfg.Mix.String()
+	}
+
+	for _, w := range workers {
+		res.Ops += w.ops
+		res.Misses += w.misses
+		hist.merge(&w.hist)
+	}
+
+	res.Latency = Latency{
+		P50:  hist.percentile(50),
+		P90:  hist.percentile(90),
+		P99:  hist.percentile(99),
+		P999: hist.percentile(99.9),
+		Max:  hist.max,
+	}
+
+	if res.Ops > 0 {
+		res.Throughput = float64(res.Ops) / elapsed.Seconds()
+		res.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / float64(res.Ops)
+		res.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / float64(res.Ops)
+	}
+
+	return res, nil
+}
+
+func (w *worker) run(target Target, keys int, stop *atomic.Bool) {
+	for (w.quota < 0 || w.ops < w.quota) && !stop.Load() {
+		op := w.mix.Pick(w.rng)
+		key := w.rng.Intn(keys)
+
+		start := time.Now()
+		found := true
+		switch op {
+		case Insert:
+			target.Insert(key)
+		case Lookup:
+			found = target.Lookup(key)
+		case Remove:
+			_, found = target.Remove(key)
+		}
+		w.hist.record(time.Since(start))
+
+		w.ops++
+		if !found {
+			w.misses++
+		}
+	}
+}
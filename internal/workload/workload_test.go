@@ -0,0 +1,195 @@
+package workload
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseMix(t *testing.T) {
+	type test struct {
+		in      string
+		want    Mix
+		wantErr bool
+	}
+
+	tests := []test{
+		{in: "25:50:25", want: Mix{Insert: 25, Lookup: 50, Remove: 25}},
+		{in: "1:0:0", want: Mix{Insert: 1}},
+		{in: " 0 : 1 : 0 ", want: Mix{Lookup: 1}},
+		{in: "0:0:0", wantErr: true},
+		{in: "1:2", wantErr: true},
+		{in: "1:-1:1", wantErr: true},
+		{in: "a:b:c", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMix(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Select(t *testing.T) {
+	got, err := Select("queue, hashmap/striped,queue")
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	want := []string{"queue", "queue/recycled", "queue/striped", "hashmap/striped"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got, _ := Select("all"); len(got) != len(Structures()) {
+		t.Errorf("got %v, want %v", len(got), len(Structures()))
+	}
+
+	if _, err := Select("hash"); err == nil {
+		t.Errorf("got %v, want an error", err)
+	}
+}
+
+func Test_Histogram(t *testing.T) {
+	var h histogram
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		want := time.Duration(p*10) * time.Microsecond
+		got := h.percentile(p)
+
+		// buckets are less than 1/16th of their value wide
+		if got < want || got > want+want/16 {
+			t.Errorf("p%v: got %v, want %v", p, got, want)
+		}
+	}
+
+	if got := h.percentile(100); got != time.Millisecond {
+		t.Errorf("got %v, want %v", got, time.Millisecond)
+	}
+
+	// every value falls in the bucket whose bounds enclose it
+	for _, d := range []time.Duration{0, 1, 15, 16, 17, 1000, 1 << 40, 1<<62 + 1} {
+		i := bucketOf(d)
+		if i >= buckets || bucketMax(i) < d || (i > 0 && bucketMax(i-1) >= d) {
+			t.Errorf("%v: got bucket %v up to %v", d, i, bucketMax(i))
+		}
+	}
+}
+
+func Test_Run(t *testing.T) {
+	const ops = 2000
+
+	for _, name := range Structures() {
+		t.Run(name, func(t *testing.T) {
+			res, err := Run(Config{
+				Structure: name,
+				Producers: 1,
+				Consumers: 1,
+				Workers:   2,
+				Mix:       DefaultMix,
+				Ops:       ops,
+				Keys:      64,
+				Prefill:   32,
+				Seed:      1,
+			})
+			if err != nil {
+				t.Fatalf("got %v, want %v", err, nil)
+			}
+
+			if res.Ops != ops {
+				t.Errorf("got %v, want %v", res.Ops, ops)
+			}
+
+			if res.Throughput <= 0 || res.Latency.Max <= 0 {
+				t.Errorf("got %+v, want measures", res)
+			}
+		})
+	}
+}
+
+func Test_Run_Config(t *testing.T) {
+	t.Run("duration", func(t *testing.T) {
+		res, err := Run(Config{Structure: "queue", Workers: 2, Mix: DefaultMix, Duration: 20 * time.Millisecond, Keys: 1})
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		if res.Elapsed < 20*time.Millisecond || res.Ops == 0 {
+			t.Errorf("got %v operations in %v", res.Ops, res.Elapsed)
+		}
+	})
+
+	t.Run("fewer operations than goroutines", func(t *testing.T) {
+		res, err := Run(Config{Structure: "queue", Producers: 4, Ops: 3, Keys: 1})
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		if res.Ops != 3 {
+			t.Errorf("got %v, want %v", res.Ops, 3)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		configs := []Config{
+			{Structure: "nope", Workers: 1, Mix: DefaultMix, Ops: 1, Keys: 1},
+			{Structure: "queue", Ops: 1, Keys: 1},
+			{Structure: "queue", Workers: 1, Ops: 1, Keys: 1},
+			{Structure: "queue", Workers: 1, Mix: DefaultMix, Keys: 1},
+			{Structure: "queue", Workers: 1, Mix: DefaultMix, Ops: 1},
+			{Structure: "queue", Producers: -1, Workers: 2, Mix: DefaultMix, Ops: 1, Keys: 1},
+		}
+
+		for _, cfg := range configs {
+			if _, err := Run(cfg); err == nil {
+				t.Errorf("%+v: got %v, want an error", cfg, err)
+			}
+		}
+	})
+}
+
+func Test_Write(t *testing.T) {
+	results := []Result{
+		{Structure: "queue", Goroutines: 2, Ops: 10, Latency: Latency{P50: 1234567}},
+		{Structure: "ctrie", Goroutines: 2, Mix: "1:1:1", Ops: 10},
+	}
+
+	var table bytes.Buffer
+	if err := WriteTable(&table, results); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(table.String()), "\n"); len(lines) != 3 {
+		t.Errorf("got %v lines, want %v", len(lines), 3)
+	}
+
+	if !strings.Contains(table.String(), "1.23ms") {
+		t.Errorf("got %q, want the rounded p50", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, results); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var decoded []Result
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if len(decoded) != 2 || decoded[0] != results[0] || decoded[1] != results[1] {
+		t.Errorf("got %+v, want %+v", decoded, results)
+	}
+}
@@ -1,5 +1,213 @@
+// Command godc runs workloads against the structures of the library to
+// compare them without writing Go benchmarks.
+//
+// Usage:
+//
+//	godc run [flags]       run workloads and report their measures
+//	godc stress [flags]    stress a queue or a map and check its invariants
+//	godc list              list the structures
+//
+// Run godc <command> -h for the flags.
 package main
 
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/stress"
+	"github.com/tangledbytes/godc/internal/workload"
+)
+
+const usage = `usage: godc <command> [flags]
+
+commands:
+  run       run workloads and report their measures
+  stress    stress a queue or a map and check its invariants
+  list      list the structures
+`
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs a command and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "run":
+		err = runWorkloads(args[1:], stdout, stderr)
+	case "stress":
+		err = runStress(args[1:], stdout, stderr)
+	case "list":
+		for _, name := range workload.Structures() {
+			fmt.Fprintln(stdout, name)
+		}
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+	default:
+		fmt.Fprintf(stderr, "godc: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case err != nil:
+		fmt.Fprintf(stderr, "godc: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func runWorkloads(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("godc run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		structures = fs.String("structure", "queue", "comma separated structures or groups to run against, or all")
+		producers  = fs.Int("producers", 0, "goroutines which only insert")
+		consumers  = fs.Int("consumers", 0, "goroutines which only remove")
+		workers    = fs.Int("workers", 0, "goroutines which follow the mix, GOMAXPROCS if there are no goroutines at all")
+		mix        = fs.String("mix", workload.DefaultMix.String(), "insert:lookup:remove weights of the workers")
+		duration   = fs.Duration("duration", time.Second, "how long every workload runs, 0 for as long as it takes to make -ops operations")
+		ops        = fs.Int64("ops", 0, "operations after which every workload stops, 0 for no limit")
+		keys       = fs.Int("keys", 1024, "size of the key space")
+		prefill    = fs.Int("prefill", 0, "keys inserted before every workload starts")
+		seed       = fs.Int64("seed", 1, "seed of the random choices")
+		format     = fs.String("format", "table", "output format, table or json")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	write := workload.WriteTable
+	switch *format {
+	case "table":
+	case "json":
+		write = workload.WriteJSON
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	m, err := workload.ParseMix(*mix)
+	if err != nil {
+		return err
+	}
+
+	names, err := workload.Select(*structures)
+	if err != nil {
+		return err
+	}
+
+	cfg := workload.Config{
+		Producers: *producers,
+		Consumers: *consumers,
+		Workers:   *workers,
+		Mix:       m,
+		Duration:  *duration,
+		Ops:       *ops,
+		Keys:      *keys,
+		Prefill:   *prefill,
+		Seed:      *seed,
+	}
+	if cfg.Producers == 0 && cfg.Consumers == 0 && cfg.Workers == 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	results := make([]workload.Result, 0, len(names))
+	for _, name := range names {
+		cfg.Structure = name
+
+		res, err := workload.Run(cfg)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+	}
+
+	return write(stdout, results)
+}
+
+func runStress(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("godc stress", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		structure  = fs.String("structure", "queue", "queue or map to stress")
+		duration   = fs.Duration("duration", 10*time.Minute, "how long to run, 0 for as long as it takes to run -rounds rounds")
+		rounds     = fs.Int("rounds", 0, "rounds after which to stop, 0 for no limit")
+		seed       = fs.Int64("seed", 0, "seed of the random choices, 0 for a new one")
+		goroutines = fs.Int("goroutines", 2*runtime.GOMAXPROCS(0), "most goroutines of a round")
+		ops        = fs.Int("ops", 10000, "most operations of a goroutine in a round")
+		keys       = fs.Int("keys", 1024, "size of the key space of maps")
+		tail       = fs.Int("log", 50, "operations of every goroutine to dump on failure, 0 for all")
+		dump       = fs.String("dump", "", "file to dump failures to instead of stderr")
+		progress   = fs.Duration("progress", 10*time.Second, "how often to report progress")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	fmt.Fprintf(stdout, "stressing %v with seed %v\n", *structure, *seed)
+
+	sum, err := stress.Run(stress.Config{
+		Structure:     *structure,
+		Duration:      *duration,
+		Rounds:        *rounds,
+		Seed:          *seed,
+		MaxGoroutines: *goroutines,
+		MaxOps:        *ops,
+		Keys:          *keys,
+		Progress:      stdout,
+		ProgressEvery: *progress,
+	})
+
+	var failure *stress.Failure
+	if !errors.As(err, &failure) {
+		if err == nil {
+			fmt.Fprintf(stdout, "passed %v rounds, %v operations in %v\n", sum.Rounds, sum.Ops, sum.Elapsed.Round(time.Millisecond))
+		}
+		return err
+	}
+
+	out := stderr
+	if *dump != "" {
+		f, err := os.Create(*dump)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	if err := failure.Dump(out, *tail); err != nil {
+		return err
+	}
 
+	return fmt.Errorf("%w, rerun with -structure %v -seed %v", failure, *structure, *seed)
 }
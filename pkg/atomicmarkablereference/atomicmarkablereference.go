@@ -11,10 +11,10 @@ type AtomicMarkableReference[T any] struct {
 }
 
 func New[T any](reference *T, mark bool) *AtomicMarkableReference[T] {
-	apair := atomic.Pointer[Pair[T]]{}
-	apair.Store(NewPair(reference, mark))
+	amr := &AtomicMarkableReference[T]{}
+	amr.pair.Store(NewPair(reference, mark))
 
-	return &AtomicMarkableReference[T]{pair: apair}
+	return amr
 }
 
 // CompareAndSet sets the value to the given updated value if the
@@ -28,7 +28,7 @@ func (amr *AtomicMarkableReference[T]) CompareAndSet(
 ) bool {
 	curr := amr.pair.Load()
 
-	if oldref != curr.Reference && oldmark != curr.Mark {
+	if oldref != curr.Reference || oldmark != curr.Mark {
 		return false
 	}
 
@@ -0,0 +1,183 @@
+package atomicmarkablereference_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+	"github.com/tangledbytes/godc/pkg/atomicmarkablereference"
+)
+
+const (
+	fuzzGoroutines = 4
+
+	// fuzzMaxOps keeps the histories small enough to check quickly
+	fuzzMaxOps = 64
+
+	// fuzzRefs is the number of distinct references, one of which is nil
+	fuzzRefs = 5
+)
+
+type fuzzOpKind byte
+
+const (
+	fuzzSet fuzzOpKind = iota
+	fuzzCAS
+	fuzzGet
+)
+
+type fuzzInput struct {
+	kind    fuzzOpKind
+	oldref  *int
+	newref  *int
+	oldmark bool
+	newmark bool
+}
+
+type fuzzOutput struct {
+	ref  *int
+	mark bool
+	ok   bool
+}
+
+type fuzzOp struct {
+	goroutine int
+	input     fuzzInput
+}
+
+type fuzzState = atomicmarkablereference.Pair[int]
+
+// decodeFuzzProgram decodes the fuzzer's input into the initial pair and
+// a program. The first byte picks the initial pair, then every pair of
+// bytes is an operation: the low two bits of the first byte pick the
+// operation, the next two the goroutine running it and the next two the
+// expected and new marks, while the two halves of the second byte pick the
+// expected and new references.
+func decodeFuzzProgram(data []byte, refs []*int) (fuzzState, []fuzzOp) {
+	var init fuzzState
+	if len(data) > 0 {
+		init = fuzzState{Reference: refs[int(data[0]>>1)%fuzzRefs], Mark: data[0]&1 == 1}
+		data = data[1:]
+	}
+
+	var ops []fuzzOp
+	for i := 0; i+1 < len(data) && len(ops) < fuzzMaxOps; i += 2 {
+		ops = append(ops, fuzzOp{
+			goroutine: int(data[i]>>2&3) % fuzzGoroutines,
+			input: fuzzInput{
+				kind:    fuzzOpKind(data[i]&3) % 3,
+				oldmark: data[i]>>4&1 == 1,
+				newmark: data[i]>>5&1 == 1,
+				oldref:  refs[int(data[i+1]&15)%fuzzRefs],
+				newref:  refs[int(data[i+1]>>4)%fuzzRefs],
+			},
+		})
+	}
+
+	return init, ops
+}
+
+func fuzzModel(init fuzzState) lincheck.Model[fuzzState, fuzzInput, fuzzOutput] {
+	return lincheck.Model[fuzzState, fuzzInput, fuzzOutput]{
+		Init: func() fuzzState {
+			return init
+		},
+		Step: func(state fuzzState, input fuzzInput, output fuzzOutput) (bool, fuzzState) {
+			switch input.kind {
+			case fuzzSet:
+				return true, fuzzState{Reference: input.newref, Mark: input.newmark}
+			case fuzzCAS:
+				if state.Reference != input.oldref || state.Mark != input.oldmark {
+					return !output.ok, state
+				}
+				return output.ok, fuzzState{Reference: input.newref, Mark: input.newmark}
+			default:
+				return output.ref == state.Reference && output.mark == state.Mark, state
+			}
+		},
+		// references are compared by identity, not by what they point to
+		Equal: func(a, b fuzzState) bool {
+			return a == b
+		},
+	}
+}
+
+func apply(amr *atomicmarkablereference.AtomicMarkableReference[int], input fuzzInput) fuzzOutput {
+	switch input.kind {
+	case fuzzSet:
+		amr.Set(input.newref, input.newmark)
+		return fuzzOutput{}
+	case fuzzCAS:
+		ok := amr.CompareAndSet(input.oldref, input.newref, input.oldmark, input.newmark)
+		return fuzzOutput{ok: ok}
+	default:
+		ref, mark := amr.Get()
+		return fuzzOutput{ref: ref, mark: mark}
+	}
+}
+
+func Fuzz_AtomicMarkableReference(f *testing.F) {
+	f.Add([]byte{0, 0x01, 0x10, 0x02, 0x00, 0x31, 0x21})
+	f.Add([]byte{3, 0x05, 0x21, 0x09, 0x21, 0x0e, 0x00, 0x31, 0x12})
+	f.Add([]byte{8, 0x01, 0x44, 0x11, 0x44, 0x25, 0x34, 0x02, 0x00, 0x0a, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		refs := []*int{nil, new(int), new(int), new(int), new(int)}
+		init, ops := decodeFuzzProgram(data, refs)
+		model := fuzzModel(init)
+
+		t.Run("sequential", func(t *testing.T) {
+			amr := atomicmarkablereference.New(init.Reference, init.Mark)
+
+			state := model.Init()
+			for _, op := range ops {
+				output := apply(amr, op.input)
+
+				ok, next := model.Step(state, op.input, output)
+				if !ok {
+					t.Fatalf("got %+v for %+v in state %+v", output, op.input, state)
+				}
+				state = next
+			}
+
+			if ref, mark := amr.Get(); ref != state.Reference || mark != state.Mark {
+				t.Errorf("got %v %v, want %v %v", ref, mark, state.Reference, state.Mark)
+			}
+		})
+
+		t.Run("concurrent", func(t *testing.T) {
+			amr := atomicmarkablereference.New(init.Reference, init.Mark)
+			r := lincheck.NewRecorder[fuzzInput, fuzzOutput]()
+
+			programs := make([][]fuzzOp, fuzzGoroutines)
+			for _, op := range ops {
+				programs[op.goroutine] = append(programs[op.goroutine], op)
+			}
+
+			var wg sync.WaitGroup
+			for g, program := range programs {
+				wg.Add(1)
+				go func(g int, program []fuzzOp) {
+					defer wg.Done()
+
+					for _, op := range program {
+						input := op.input
+						r.Record(g, input, func() fuzzOutput {
+							return apply(amr, input)
+						})
+					}
+				}(g, program)
+			}
+			wg.Wait()
+
+			// the final pair must be one the model can end up in
+			r.Record(fuzzGoroutines, fuzzInput{kind: fuzzGet}, func() fuzzOutput {
+				return apply(amr, fuzzInput{kind: fuzzGet})
+			})
+
+			if !lincheck.Check(model, r.History()) {
+				t.Errorf("history is not linearizable: %v", r.History())
+			}
+		})
+	})
+}
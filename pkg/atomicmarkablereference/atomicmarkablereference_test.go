@@ -402,3 +402,46 @@ func Test_AtomicMarkableReference_CompareAndSet_Multi(t *testing.T) {
 		}
 	})
 }
+
+func Test_AtomicMarkableReference_CompareAndSet_Mismatch(t *testing.T) {
+	one := 1
+	two := 2
+
+	type test struct {
+		name    string
+		oldref  *int
+		oldmark bool
+	}
+
+	tests := []test{
+		{
+			name:    "when only the reference differs",
+			oldref:  &two,
+			oldmark: false,
+		},
+		{
+			name:    "when only the mark differs",
+			oldref:  &one,
+			oldmark: true,
+		},
+		{
+			name:    "when both differ",
+			oldref:  &two,
+			oldmark: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mref := atomicmarkablereference.New(&one, false)
+
+			if val := mref.CompareAndSet(tt.oldref, &two, tt.oldmark, true); val {
+				t.Errorf("got %v, want %v", val, false)
+			}
+
+			if ref, mark := mref.Get(); ref != &one || mark {
+				t.Errorf("got %v, want %v", [2]interface{}{ref, mark}, [2]interface{}{&one, false})
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package barrier provides reusable barriers which make a fixed number of
+// goroutines wait for each other before moving on to their next phase.
+//
+// Barriers other than Sense need to know which participant is calling, so
+// each goroutine takes a handle from Participant once and calls Await on
+// it in every phase. A handle must not be shared between goroutines.
+//
+// Waiting goroutines spin for a short while and then yield the processor
+// on every iteration. The barriers are adapted from The Art of
+// Multiprocessor Programming, chapter 17.
+package barrier
+
+// Barrier makes goroutines wait for each other.
+type Barrier interface {
+	// Await blocks until all participants called it.
+	Await()
+}
+
+var (
+	_ Barrier = (*Sense)(nil)
+	_ Barrier = (*treeParticipant)(nil)
+	_ Barrier = (*staticParticipant)(nil)
+	_ Barrier = (*disseminationParticipant)(nil)
+)
+
+func checkParticipants(n int) {
+	if n < 1 {
+		panic("barrier: number of participants must be positive")
+	}
+}
+
+func checkID(id, n int) {
+	if id < 0 || id >= n {
+		panic("barrier: participant id out of range")
+	}
+}
@@ -0,0 +1,53 @@
+//go:build godc_sched
+
+package barrier
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+func Test_Barrier_Schedules(t *testing.T) {
+	const n, phases = 4, 3
+
+	for name, newBarrier := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			// every participant records the phase it is in, no one may
+			// leave a phase before everyone got to it
+			program := func() ([]func(), func() error) {
+				participant := newBarrier(n)
+				progress := make([]int, n)
+				var err error
+
+				bodies := make([]func(), n)
+				for id := range bodies {
+					id := id
+					bodies[id] = func() {
+						p := participant(id)
+						for phase := 1; phase <= phases; phase++ {
+							progress[id] = phase
+							sched.Yield("barrier: arrived")
+							p.Await()
+
+							for other, got := range progress {
+								if got < phase && err == nil {
+									err = fmt.Errorf("participant %v left phase %v before %v got to it", id, phase, other)
+								}
+							}
+						}
+					}
+				}
+
+				return bodies, func() error {
+					return err
+				}
+			}
+
+			if f := sched.Random(program, 1, 200); f != nil {
+				t.Errorf("%v", sched.Shrink(program, f))
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package barrier
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tangledbytes/godc/pkg/queue"
+)
+
+// implementations returns constructors which set up a barrier for n
+// participants and return a function handing out their handles.
+func implementations() map[string]func(n int) func(id int) Barrier {
+	return map[string]func(n int) func(id int) Barrier{
+		"sense": func(n int) func(id int) Barrier {
+			b := NewSense(n)
+			return func(int) Barrier { return b }
+		},
+		"combining tree": func(n int) func(id int) Barrier {
+			return NewCombiningTree(n, 2).Participant
+		},
+		"static tree": func(n int) func(id int) Barrier {
+			return NewStaticTree(n, 3).Participant
+		},
+		"dissemination": func(n int) func(id int) Barrier {
+			return NewDissemination(n).Participant
+		},
+	}
+}
+
+func Test_Barrier_Await(t *testing.T) {
+	const phases = 200
+
+	for name, newBarrier := range implementations() {
+		for _, n := range []int{2, 8, 16} {
+			t.Run(fmt.Sprintf("%v - %v participants", name, n), func(t *testing.T) {
+				participant := newBarrier(n)
+
+				// every participant records the phase it is in, no one may
+				// leave a phase before everyone got to it
+				progress := make([]atomic.Int64, n)
+
+				var wg sync.WaitGroup
+				for id := 0; id < n; id++ {
+					wg.Add(1)
+					go func(id int) {
+						defer wg.Done()
+
+						b := participant(id)
+						for phase := int64(1); phase <= phases; phase++ {
+							progress[id].Store(phase)
+							b.Await()
+
+							for other := range progress {
+								if got := progress[other].Load(); got < phase {
+									t.Errorf("got participant %v in phase %v, want at least %v", other, got, phase)
+									return
+								}
+							}
+
+							// and no one may get to the next phase before
+							// everyone is done checking
+							b.Await()
+						}
+					}(id)
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
+
+func Test_Barrier_Single(t *testing.T) {
+	for name, newBarrier := range map[string]func() Barrier{
+		"sense": func() Barrier {
+			return NewSense(1)
+		},
+		"static tree": func() Barrier {
+			return NewStaticTree(1, 2).Participant(0)
+		},
+		"dissemination": func() Barrier {
+			return NewDissemination(1).Participant(0)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := newBarrier()
+
+			for i := 0; i < 10; i++ {
+				b.Await()
+			}
+		})
+	}
+}
+
+func Test_Barrier_Invalid(t *testing.T) {
+	tests := map[string]func(){
+		"no participants": func() {
+			NewSense(0)
+		},
+		"not a power of the radix": func() {
+			NewCombiningTree(6, 2)
+		},
+		"radix too small": func() {
+			NewCombiningTree(4, 1)
+		},
+		"participant out of range": func() {
+			NewDissemination(4).Participant(4)
+		},
+	}
+
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("did not panic")
+				}
+			}()
+
+			f()
+		})
+	}
+}
+
+func Test_TerminationDetector(t *testing.T) {
+	const workers, depth = 8, 12
+
+	d := NewTerminationDetector(workers)
+
+	// every task of depth k > 0 spawns two tasks of depth k-1, so the
+	// workers must run 2^(depth+1)-1 tasks before they are done
+	tasks := queue.New[int]()
+	tasks.Push(depth)
+
+	var ran atomic.Int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				for task, ok := tasks.Pop(); ok; task, ok = tasks.Pop() {
+					ran.Add(1)
+					if task > 0 {
+						tasks.Push(task - 1)
+						tasks.Push(task - 1)
+					}
+				}
+
+				d.SetActive(false)
+				for {
+					if d.Terminated() {
+						return
+					}
+
+					if _, ok := tasks.Peek(); ok {
+						d.SetActive(true)
+						break
+					}
+
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := ran.Load(), int64(1<<(depth+1)-1); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package barrier
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// disseminationFlags are the flags a participant is signalled on, one per
+// round for either parity.
+type disseminationFlags struct {
+	rounds [2][]atomic.Bool
+	_      [16]byte
+}
+
+// Dissemination is a barrier without any shared count. In round r every
+// participant i signals participant i+2^r and waits for the signal of
+// participant i-2^r, modulo n. After log n rounds every participant has
+// transitively heard from everyone else.
+//
+// Flags alternate between two sets on consecutive phases and the sense
+// they are set to flips every other phase, so they never need to be
+// reset.
+//
+// This is adapted from Hensgen, Finkel and Manber, Two Algorithms for
+// Barrier Synchronization.
+type Dissemination struct {
+	flags []disseminationFlags
+}
+
+// NewDissemination returns a barrier for n participants.
+func NewDissemination(n int) *Dissemination {
+	checkParticipants(n)
+
+	rounds := bits.Len(uint(n - 1))
+
+	b := &Dissemination{flags: make([]disseminationFlags, n)}
+	for i := range b.flags {
+		b.flags[i].rounds[0] = make([]atomic.Bool, rounds)
+		b.flags[i].rounds[1] = make([]atomic.Bool, rounds)
+	}
+
+	return b
+}
+
+// Participant returns the handle of participant id, which must be in
+// [0, n).
+func (b *Dissemination) Participant(id int) Barrier {
+	n := len(b.flags)
+	checkID(id, n)
+
+	p := &disseminationParticipant{
+		flags: &b.flags[id],
+		sense: true,
+	}
+
+	for r := range b.flags[id].rounds[0] {
+		p.partners = append(p.partners, &b.flags[(id+1<<r)%n])
+	}
+
+	return p
+}
+
+type disseminationParticipant struct {
+	flags    *disseminationFlags
+	partners []*disseminationFlags
+	parity   int
+	sense    bool
+}
+
+func (p *disseminationParticipant) Await() {
+	var s spin.Spinner
+	for r, partner := range p.partners {
+		partner.rounds[p.parity][r].Store(p.sense)
+
+		for p.flags.rounds[p.parity][r].Load() != p.sense {
+			s.Spin()
+		}
+	}
+
+	if p.parity == 1 {
+		p.sense = !p.sense
+	}
+	p.parity = 1 - p.parity
+}
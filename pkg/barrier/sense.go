@@ -0,0 +1,45 @@
+package barrier
+
+import (
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// Sense is a sense reversing barrier. Every participant decrements a
+// shared count and the last one to arrive resets it and flips the sense
+// of the barrier, which the others spin on.
+//
+// Unlike the other barriers it needs no handles. A goroutine can read the
+// sense of the current phase on arrival, since it cannot flip before the
+// goroutine itself arrived.
+type Sense struct {
+	n     int64
+	count atomic.Int64
+	sense atomic.Bool
+}
+
+// NewSense returns a barrier for n participants.
+func NewSense(n int) *Sense {
+	checkParticipants(n)
+
+	b := &Sense{n: int64(n)}
+	b.count.Store(int64(n))
+
+	return b
+}
+
+func (b *Sense) Await() {
+	mySense := !b.sense.Load()
+
+	if b.count.Add(-1) == 0 {
+		b.count.Store(b.n)
+		b.sense.Store(mySense)
+		return
+	}
+
+	var s spin.Spinner
+	for b.sense.Load() != mySense {
+		s.Spin()
+	}
+}
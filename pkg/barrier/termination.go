@@ -0,0 +1,41 @@
+package barrier
+
+import "sync/atomic"
+
+// TerminationDetector tells a fixed group of goroutines sharing work
+// among themselves when all of it is done. Every goroutine is active
+// while it has or looks for work. Once all of them are inactive at the
+// same time no new work can appear, and the computation has terminated.
+//
+// A goroutine which finds work after becoming inactive must become active
+// again before taking it, otherwise the others may see it terminated.
+type TerminationDetector struct {
+	active atomic.Int64
+}
+
+// NewTerminationDetector returns a detector for n goroutines which all
+// start out active.
+func NewTerminationDetector(n int) *TerminationDetector {
+	checkParticipants(n)
+
+	d := &TerminationDetector{}
+	d.active.Store(int64(n))
+
+	return d
+}
+
+// SetActive marks the calling goroutine as active or inactive. It must
+// only be called when that changes its state.
+func (d *TerminationDetector) SetActive(active bool) {
+	if active {
+		d.active.Add(1)
+		return
+	}
+
+	d.active.Add(-1)
+}
+
+// Terminated reports whether all goroutines are inactive.
+func (d *TerminationDetector) Terminated() bool {
+	return d.active.Load() == 0
+}
@@ -0,0 +1,178 @@
+package barrier
+
+import (
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+type treeNode struct {
+	count  atomic.Int64
+	sense  atomic.Bool
+	parent *treeNode
+	radix  int64
+
+	_ [40]byte
+}
+
+// await arrives at the node, the last one to do so goes on to the parent
+// and then releases everyone waiting at the node.
+func (n *treeNode) await(mySense bool) {
+	if n.count.Add(-1) > 0 {
+		var s spin.Spinner
+		for n.sense.Load() != mySense {
+			s.Spin()
+		}
+		return
+	}
+
+	if n.parent != nil {
+		n.parent.await(mySense)
+	}
+
+	n.count.Store(n.radix)
+	n.sense.Store(mySense)
+}
+
+// CombiningTree is a barrier where participants are split over the leaves
+// of a tree and only the last one to arrive at a node goes on to its
+// parent. Each node is shared by only radix goroutines, which spreads the
+// contention of a single shared count.
+type CombiningTree struct {
+	n      int
+	radix  int
+	leaves []*treeNode
+}
+
+// NewCombiningTree returns a barrier for n participants. The radix is the
+// number of participants meeting at each node and n must be a positive
+// power of it.
+func NewCombiningTree(n, radix int) *CombiningTree {
+	checkParticipants(n)
+	if radix < 2 {
+		panic("barrier: radix must be at least 2")
+	}
+
+	size := radix
+	for size < n {
+		size *= radix
+	}
+	if size != n {
+		panic("barrier: number of participants must be a power of the radix")
+	}
+
+	newNode := func(parent *treeNode) *treeNode {
+		node := &treeNode{parent: parent, radix: int64(radix)}
+		node.count.Store(int64(radix))
+
+		return node
+	}
+
+	// build the tree top down, level by level
+	level := []*treeNode{newNode(nil)}
+	for len(level)*radix < n {
+		next := make([]*treeNode, 0, len(level)*radix)
+		for _, parent := range level {
+			for i := 0; i < radix; i++ {
+				next = append(next, newNode(parent))
+			}
+		}
+		level = next
+	}
+
+	return &CombiningTree{n: n, radix: radix, leaves: level}
+}
+
+// Participant returns the handle of participant id, which must be in
+// [0, n).
+func (b *CombiningTree) Participant(id int) Barrier {
+	checkID(id, b.n)
+
+	return &treeParticipant{leaf: b.leaves[id/b.radix], sense: true}
+}
+
+type treeParticipant struct {
+	leaf  *treeNode
+	sense bool
+}
+
+func (p *treeParticipant) Await() {
+	p.leaf.await(p.sense)
+	p.sense = !p.sense
+}
+
+type staticNode struct {
+	children   int64
+	childCount atomic.Int64
+	parent     *staticNode
+
+	_ [40]byte
+}
+
+// StaticTree is a barrier where every participant owns a node of a tree.
+// A participant waits for all of its children to arrive and then tells
+// its parent, once the root hears from all of its children it flips the
+// global sense everyone else spins on. Each participant only ever waits
+// on its own node and the sense.
+type StaticTree struct {
+	nodes []staticNode
+	sense atomic.Bool
+}
+
+// NewStaticTree returns a barrier for n participants where every node has
+// up to radix children.
+func NewStaticTree(n, radix int) *StaticTree {
+	checkParticipants(n)
+	if radix < 1 {
+		panic("barrier: radix must be positive")
+	}
+
+	// the tree is laid out as a heap, the parent of node i is (i-1)/radix
+	b := &StaticTree{nodes: make([]staticNode, n)}
+	for i := 1; i < n; i++ {
+		parent := &b.nodes[(i-1)/radix]
+		parent.children++
+		b.nodes[i].parent = parent
+	}
+
+	for i := range b.nodes {
+		b.nodes[i].childCount.Store(b.nodes[i].children)
+	}
+
+	return b
+}
+
+// Participant returns the handle of participant id, which must be in
+// [0, n).
+func (b *StaticTree) Participant(id int) Barrier {
+	checkID(id, len(b.nodes))
+
+	return &staticParticipant{barrier: b, node: &b.nodes[id], sense: true}
+}
+
+type staticParticipant struct {
+	barrier *StaticTree
+	node    *staticNode
+	sense   bool
+}
+
+func (p *staticParticipant) Await() {
+	node := p.node
+
+	var s spin.Spinner
+	for node.childCount.Load() > 0 {
+		s.Spin()
+	}
+	node.childCount.Store(node.children)
+
+	if node.parent == nil {
+		p.barrier.sense.Store(p.sense)
+	} else {
+		node.parent.childCount.Add(-1)
+		for p.barrier.sense.Load() != p.sense {
+			s.Spin()
+		}
+	}
+
+	p.sense = !p.sense
+}
@@ -0,0 +1,214 @@
+package counter
+
+import (
+	"math/rand"
+	"sync"
+)
+
+type combiningStatus int
+
+const (
+	// idle nodes are not in use
+	idle combiningStatus = iota
+
+	// first means a goroutine got to the node first and may carry the
+	// increments combined at it further up the tree
+	first
+
+	// second means a second goroutine got to the node and waits for the
+	// first one to bring back its result
+	second
+
+	// result means the result for the second goroutine is ready
+	result
+
+	// root marks the root node which holds the counter value
+	root
+)
+
+type combiningNode struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	status      combiningStatus
+	locked      bool
+	firstValue  int64
+	secondValue int64
+	result      int64
+
+	parent *combiningNode
+}
+
+func newCombiningNode(parent *combiningNode) *combiningNode {
+	n := &combiningNode{parent: parent}
+	n.cond = sync.NewCond(&n.mu)
+
+	if parent == nil {
+		n.status = root
+	}
+
+	return n
+}
+
+// precombine reports whether the caller got to the node first and should
+// keep climbing.
+func (n *combiningNode) precombine() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// a goroutine arriving while a combining round is in progress waits
+	// for it, this lets any number of goroutines share a leaf
+	for n.locked || n.status == second {
+		n.cond.Wait()
+	}
+
+	switch n.status {
+	case idle:
+		n.status = first
+		return true
+	case first:
+		n.locked = true
+		n.status = second
+		return false
+	case root:
+		return false
+	default:
+		panic("counter: unexpected combining tree node status")
+	}
+}
+
+// combine locks the node against late arrivals and returns the increments
+// combined at it.
+func (n *combiningNode) combine(combined int64) int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for n.locked {
+		n.cond.Wait()
+	}
+	n.locked = true
+	n.firstValue = combined
+
+	switch n.status {
+	case first:
+		return n.firstValue
+	case second:
+		return n.firstValue + n.secondValue
+	default:
+		panic("counter: unexpected combining tree node status")
+	}
+}
+
+// op applies the combined increments at the root, or at any other node
+// hands them to the first goroutine and waits for the result.
+func (n *combiningNode) op(combined int64) int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch n.status {
+	case root:
+		prior := n.result
+		n.result += combined
+		return prior
+	case second:
+		n.secondValue = combined
+		n.locked = false
+		n.cond.Broadcast()
+
+		for n.status != result {
+			n.cond.Wait()
+		}
+
+		n.locked = false
+		n.status = idle
+		n.cond.Broadcast()
+		return n.result
+	default:
+		panic("counter: unexpected combining tree node status")
+	}
+}
+
+// distribute hands the result down to the second goroutine, if any, and
+// releases the node.
+func (n *combiningNode) distribute(prior int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch n.status {
+	case first:
+		n.status = idle
+		n.locked = false
+	case second:
+		n.result = prior + n.firstValue
+		n.status = result
+	default:
+		panic("counter: unexpected combining tree node status")
+	}
+
+	n.cond.Broadcast()
+}
+
+// CombiningTree is a shared counter where goroutines meeting at a node of
+// a binary tree combine their increments so that only one of them goes
+// on to the root. Under heavy contention this trades latency for far
+// fewer updates to the single hot value.
+//
+// Goroutines pick a random leaf on every call, the tree performs best
+// when there are about twice as many concurrent callers as leaves.
+//
+// This is adapted from The Art of Multiprocessor Programming, 12.3.
+type CombiningTree struct {
+	leaves []*combiningNode
+}
+
+// NewCombiningTree returns a combining tree for width concurrent callers.
+// The width must be a power of two and at least 2.
+func NewCombiningTree(width int) *CombiningTree {
+	if width < 2 || width&(width-1) != 0 {
+		panic("counter: combining tree width must be a power of two >= 2")
+	}
+
+	// the tree is laid out as a heap, the parent of node i is (i-1)/2
+	nodes := make([]*combiningNode, width-1)
+	nodes[0] = newCombiningNode(nil)
+	for i := 1; i < len(nodes); i++ {
+		nodes[i] = newCombiningNode(nodes[(i-1)/2])
+	}
+
+	leaves := make([]*combiningNode, width/2)
+	for i := range leaves {
+		leaves[i] = nodes[len(nodes)-i-1]
+	}
+
+	return &CombiningTree{leaves: leaves}
+}
+
+// GetAndIncrement increments the counter and returns its prior value.
+func (t *CombiningTree) GetAndIncrement() int64 {
+	leaf := t.leaves[rand.Intn(len(t.leaves))]
+
+	// precombining - climb while we are the first at each node
+	node := leaf
+	for node.precombine() {
+		node = node.parent
+	}
+	stop := node
+
+	// combining - collect the increments of whoever we met on the way
+	var path []*combiningNode
+	combined := int64(1)
+	for node = leaf; node != stop; node = node.parent {
+		combined = node.combine(combined)
+		path = append(path, node)
+	}
+
+	// operation
+	prior := stop.op(combined)
+
+	// distribution - hand the results back down
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].distribute(prior)
+	}
+
+	return prior
+}
@@ -0,0 +1,145 @@
+package counter
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// prismSpins is how many times a goroutine looks for a partner in a prism
+// before falling back to the toggle of the balancer.
+const prismSpins = 64
+
+type offer struct {
+	matched atomic.Bool
+}
+
+// exchanger is a meeting point for two goroutines. Unlike a full
+// exchanger no values are swapped, the two only learn which of them
+// arrived first.
+type exchanger struct {
+	slot atomic.Pointer[offer]
+}
+
+// visit waits up to prismSpins for a partner. It reports whether a
+// partner was met and if so, whether the caller arrived first.
+func (e *exchanger) visit() (isFirst, ok bool) {
+	for i := 0; i < prismSpins; i++ {
+		o := e.slot.Load()
+		if o != nil {
+			// someone is waiting, take their offer
+			if e.slot.CompareAndSwap(o, nil) {
+				o.matched.Store(true)
+				return false, true
+			}
+			continue
+		}
+
+		mine := &offer{}
+		if !e.slot.CompareAndSwap(nil, mine) {
+			continue
+		}
+
+		for j := i; j < prismSpins; j++ {
+			if mine.matched.Load() {
+				return true, true
+			}
+		}
+
+		// withdraw the offer unless someone took it in the meantime
+		if e.slot.CompareAndSwap(mine, nil) {
+			return false, false
+		}
+
+		return true, true
+	}
+
+	return false, false
+}
+
+// diffractingBalancer sends goroutines alternately to its two outputs.
+// Pairs which meet in the prism go one each way without touching the
+// toggle, which is what takes the contention off it.
+type diffractingBalancer struct {
+	prism  []exchanger
+	toggle atomic.Uint64
+}
+
+func (b *diffractingBalancer) traverse() int {
+	if isFirst, ok := b.prism[rand.Intn(len(b.prism))].visit(); ok {
+		if isFirst {
+			return 0
+		}
+		return 1
+	}
+
+	return int((b.toggle.Add(1) - 1) & 1)
+}
+
+type diffractingNode struct {
+	balancer diffractingBalancer
+	children [2]*diffractingNode
+}
+
+func newDiffractingNode(width int) *diffractingNode {
+	n := &diffractingNode{}
+	n.balancer.prism = make([]exchanger, width/2)
+
+	if width > 2 {
+		n.children[0] = newDiffractingNode(width / 2)
+		n.children[1] = newDiffractingNode(width / 2)
+	}
+
+	return n
+}
+
+// traverse returns the output wire the caller leaves the tree on.
+func (n *diffractingNode) traverse() int {
+	half := n.balancer.traverse()
+	if n.children[half] == nil {
+		return half
+	}
+
+	return 2*n.children[half].traverse() + half
+}
+
+// DiffractingTree is a shared counter built on a tree shaped counting
+// network. Every balancer of the tree has a prism where pairs of
+// goroutines cancel out and leave on different wires, so that under load
+// most of them never touch a shared toggle. Each output wire i hands out
+// the values i, i+width, i+2*width and so on.
+//
+// This is adapted from The Art of Multiprocessor Programming, 12.6.
+type DiffractingTree struct {
+	root  *diffractingNode
+	wires []cell
+}
+
+// NewDiffractingTree returns a diffracting tree with width output wires.
+// The width must be a power of two and at least 2.
+func NewDiffractingTree(width int) *DiffractingTree {
+	if width < 2 || width&(width-1) != 0 {
+		panic("counter: diffracting tree width must be a power of two >= 2")
+	}
+
+	t := &DiffractingTree{
+		root:  newDiffractingNode(width),
+		wires: make([]cell, width),
+	}
+
+	for i := range t.wires {
+		t.wires[i].v.Store(int64(i))
+	}
+
+	return t
+}
+
+// GetAndIncrement increments the counter and returns its prior value.
+//
+// Values are unique but only ordered once the tree is quiescent, two
+// concurrent calls may return their values in either order.
+func (t *DiffractingTree) GetAndIncrement() int64 {
+	wire := t.root.traverse()
+	width := int64(len(t.wires))
+
+	return t.wires[wire].v.Add(width) - width
+}
@@ -0,0 +1,144 @@
+// Package counter provides concurrent counters which scale better than
+// a single atomic integer under contention.
+package counter
+
+import (
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+// cell is a counter slot padded to a cache line of its own so that
+// goroutines updating neighbouring cells do not false share.
+type cell struct {
+	v atomic.Int64
+	_ [56]byte
+}
+
+// Striped is a counter which spreads updates over several cells so that
+// concurrent Adds rarely touch the same cache line. The zero value is
+// ready to use.
+//
+// While uncontended every Add goes to a single base value. Once a CAS on
+// it fails the counter switches to cells, which are doubled on contention
+// up to the number of CPUs. Go does not expose which P a goroutine runs
+// on so a cell is picked at random on every Add.
+//
+// This is adapted from OpenJDK's java.util.concurrent.atomic.LongAdder.
+type Striped struct {
+	base  atomic.Int64
+	cells atomic.Pointer[[]*cell]
+
+	// growing guards replacing the cells
+	growing atomic.Bool
+}
+
+func NewStriped() *Striped {
+	return &Striped{}
+}
+
+// Add adds x to the counter.
+func (c *Striped) Add(x int64) {
+	cells := c.cells.Load()
+	if cells == nil {
+		b := c.base.Load()
+		if c.base.CompareAndSwap(b, b+x) {
+			return
+		}
+
+		cells = c.grow(nil)
+	}
+
+	for {
+		cl := (*cells)[rand.Uint32()&uint32(len(*cells)-1)]
+
+		v := cl.v.Load()
+		if cl.v.CompareAndSwap(v, v+x) {
+			return
+		}
+
+		cells = c.grow(cells)
+	}
+}
+
+// Sum returns the current value of the counter.
+//
+// Sum is not an atomic snapshot, Adds which happen while it runs may or
+// may not be included.
+func (c *Striped) Sum() int64 {
+	sum := c.base.Load()
+
+	if cells := c.cells.Load(); cells != nil {
+		for _, cl := range *cells {
+			sum += cl.v.Load()
+		}
+	}
+
+	return sum
+}
+
+// Reset sets the counter to zero. It is only exact while there are no
+// concurrent Adds.
+func (c *Striped) Reset() {
+	c.base.Store(0)
+
+	if cells := c.cells.Load(); cells != nil {
+		for _, cl := range *cells {
+			cl.v.Store(0)
+		}
+	}
+}
+
+// grow doubles the cells if they are still the given ones and there is
+// room for more, and returns the current cells.
+func (c *Striped) grow(cells *[]*cell) *[]*cell {
+	if cells != nil && len(*cells) >= maxCells() {
+		return cells
+	}
+
+	if !c.growing.CompareAndSwap(false, true) {
+		// someone else is growing them, use whatever is there
+		if curr := c.cells.Load(); curr != nil {
+			return curr
+		}
+
+		for c.cells.Load() == nil {
+			runtime.Gosched()
+		}
+
+		return c.cells.Load()
+	}
+	defer c.growing.Store(false)
+
+	if curr := c.cells.Load(); curr != cells {
+		// someone beat us to it
+		return curr
+	}
+
+	n := 2
+	if cells != nil {
+		n = 2 * len(*cells)
+	}
+
+	// the existing cells are carried over so that no update is lost
+	fresh := make([]*cell, n)
+	if cells != nil {
+		copy(fresh, *cells)
+	}
+
+	for i := range fresh {
+		if fresh[i] == nil {
+			fresh[i] = &cell{}
+		}
+	}
+
+	c.cells.Store(&fresh)
+	return &fresh
+}
+
+// maxCells is the number of CPUs rounded up to a power of two, more cells
+// than that cannot all be contended at once.
+func maxCells() int {
+	return 1 << bits.Len(uint(runtime.NumCPU()-1))
+}
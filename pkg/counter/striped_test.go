@@ -0,0 +1,104 @@
+package counter
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_Striped_Add(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		type test struct {
+			name string
+			data []int64
+			want int64
+		}
+
+		tests := []test{
+			{
+				name: "empty",
+				data: []int64{},
+				want: 0,
+			},
+			{
+				name: "single element",
+				data: []int64{5},
+				want: 5,
+			},
+			{
+				name: "negative elements",
+				data: []int64{5, -3, 10, -20},
+				want: -8,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var c Striped
+
+				for _, data := range tt.data {
+					c.Add(data)
+				}
+
+				if got := c.Sum(); got != tt.want {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		c := NewStriped()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 64; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < 10000; j++ {
+					if i%2 == 0 {
+						c.Add(2)
+					} else {
+						c.Add(-1)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if got, want := c.Sum(), int64(32*10000); got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+
+		if cells := c.cells.Load(); cells != nil && len(*cells) > maxCells() && len(*cells) > 2 {
+			t.Errorf("got %v cells, want at most %v", len(*cells), maxCells())
+		}
+	})
+}
+
+func Test_Striped_Reset(t *testing.T) {
+	c := NewStriped()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				c.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.Reset()
+	if got := c.Sum(); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+
+	c.Add(3)
+	if got := c.Sum(); got != 3 {
+		t.Errorf("got %v, want %v", got, 3)
+	}
+}
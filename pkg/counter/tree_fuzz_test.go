@@ -0,0 +1,123 @@
+package counter
+
+import (
+	"sort"
+	"sync"
+	"testing"
+)
+
+const (
+	fuzzGoroutines = 4
+
+	// fuzzMaxOps keeps the runs short
+	fuzzMaxOps = 64
+)
+
+type fuzzOp struct {
+	goroutine  int
+	increments int
+}
+
+// decodeFuzzProgram decodes the fuzzer's input into the name and width of
+// the tree and a program. The low bit of the first byte picks the tree and
+// the next two bits the width, then every byte is an operation: the low
+// two bits pick the goroutine running it and the rest is the number of
+// increments it makes in a row.
+func decodeFuzzProgram(data []byte) (string, int, []fuzzOp) {
+	var names []string
+	for name := range trees() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	name, width := names[0], 2
+	if len(data) > 0 {
+		name = names[int(data[0]&1)%len(names)]
+		width = 2 << (data[0] >> 1 & 3)
+		data = data[1:]
+	}
+
+	var ops []fuzzOp
+	for i := 0; i < len(data) && len(ops) < fuzzMaxOps; i++ {
+		ops = append(ops, fuzzOp{
+			goroutine:  int(data[i]&3) % fuzzGoroutines,
+			increments: int(data[i] >> 2),
+		})
+	}
+
+	return name, width, ops
+}
+
+func Fuzz_Tree(f *testing.F) {
+	f.Add([]byte{0, 4, 5, 6, 7})
+	f.Add([]byte{1, 8, 9, 10, 11, 4, 5})
+	f.Add([]byte{7, 252, 253, 254, 255, 0, 1, 2, 3})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		name, width, ops := decodeFuzzProgram(data)
+		newTree := trees()[name]
+
+		total := 0
+		for _, op := range ops {
+			total += op.increments
+		}
+
+		t.Run("sequential", func(t *testing.T) {
+			c := newTree(width)
+
+			for want := int64(0); want < int64(total); want++ {
+				if got := c.GetAndIncrement(); got != want {
+					t.Fatalf("%v width %v: got %v, want %v", name, width, got, want)
+				}
+			}
+		})
+
+		t.Run("concurrent", func(t *testing.T) {
+			c := newTree(width)
+
+			programs := make([][]fuzzOp, fuzzGoroutines)
+			for _, op := range ops {
+				programs[op.goroutine] = append(programs[op.goroutine], op)
+			}
+
+			var mu sync.Mutex
+			var got []int64
+
+			var wg sync.WaitGroup
+			for _, program := range programs {
+				wg.Add(1)
+				go func(program []fuzzOp) {
+					defer wg.Done()
+
+					var values []int64
+					for _, op := range program {
+						for i := 0; i < op.increments; i++ {
+							values = append(values, c.GetAndIncrement())
+						}
+					}
+
+					mu.Lock()
+					got = append(got, values...)
+					mu.Unlock()
+				}(program)
+			}
+			wg.Wait()
+
+			// every value is handed out exactly once
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			for i, v := range got {
+				if v != int64(i) {
+					t.Fatalf("%v width %v: got %v at %v, want %v", name, width, v, i, i)
+				}
+			}
+
+			if len(got) != total {
+				t.Errorf("got %v values, want %v", len(got), total)
+			}
+
+			if next := c.GetAndIncrement(); next != int64(total) {
+				t.Errorf("got %v, want %v", next, total)
+			}
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package counter
+
+import (
+	"sort"
+	"sync"
+	"testing"
+)
+
+type getAndIncrementer interface {
+	GetAndIncrement() int64
+}
+
+func trees() map[string]func(width int) getAndIncrementer {
+	return map[string]func(width int) getAndIncrementer{
+		"combining": func(width int) getAndIncrementer {
+			return NewCombiningTree(width)
+		},
+		"diffracting": func(width int) getAndIncrementer {
+			return NewDiffractingTree(width)
+		},
+	}
+}
+
+func Test_Tree_GetAndIncrement(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newTree := range trees() {
+			for _, width := range []int{2, 4, 16} {
+				t.Run(name, func(t *testing.T) {
+					c := newTree(width)
+
+					for want := int64(0); want < 100; want++ {
+						if got := c.GetAndIncrement(); got != want {
+							t.Fatalf("width %v: got %v, want %v", width, got, want)
+						}
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		const goroutines, increments = 32, 500
+
+		for name, newTree := range trees() {
+			for _, width := range []int{2, 8, 32} {
+				t.Run(name, func(t *testing.T) {
+					c := newTree(width)
+
+					var mu sync.Mutex
+					var got []int64
+
+					var wg sync.WaitGroup
+					for i := 0; i < goroutines; i++ {
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+
+							values := make([]int64, 0, increments)
+							for j := 0; j < increments; j++ {
+								values = append(values, c.GetAndIncrement())
+							}
+
+							mu.Lock()
+							got = append(got, values...)
+							mu.Unlock()
+						}()
+					}
+					wg.Wait()
+
+					// every value is handed out exactly once
+					sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+					for i, v := range got {
+						if v != int64(i) {
+							t.Fatalf("width %v: got %v at %v, want %v", width, v, i, i)
+						}
+					}
+
+					if len(got) != goroutines*increments {
+						t.Errorf("got %v values, want %v", len(got), goroutines*increments)
+					}
+
+					// and the counter is quiescent again
+					if next := c.GetAndIncrement(); next != goroutines*increments {
+						t.Errorf("got %v, want %v", next, goroutines*increments)
+					}
+				})
+			}
+		}
+	})
+}
+
+func Test_Tree_InvalidWidth(t *testing.T) {
+	for name, newTree := range trees() {
+		for _, width := range []int{0, 1, 3, 12} {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("width %v did not panic", width)
+					}
+				}()
+
+				newTree(width)
+			})
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package countingnetwork
+
+// merger takes two step sequences of width/2 on its even and odd inputs
+// and merges them into a single step sequence.
+type merger struct {
+	half  [2]*merger
+	layer []Balancer
+	width int
+}
+
+func newMerger(width int) *merger {
+	m := &merger{
+		layer: make([]Balancer, width/2),
+		width: width,
+	}
+
+	if width > 2 {
+		m.half[0] = newMerger(width / 2)
+		m.half[1] = newMerger(width / 2)
+	}
+
+	return m
+}
+
+func (m *merger) traverse(input int) int {
+	output := 0
+	if m.width > 2 {
+		// the first half takes the even wires of the top inputs and the
+		// odd wires of the bottom ones, the second half the rest
+		if input < m.width/2 {
+			output = m.half[input%2].traverse(input / 2)
+		} else {
+			output = m.half[1-input%2].traverse(input / 2)
+		}
+	}
+
+	return 2*output + m.layer[output].Traverse()
+}
+
+// Bitonic is the bitonic counting network. It is built recursively from
+// two bitonic networks of half the width whose outputs are fed to a
+// merger, and has depth O(log^2 width).
+//
+// This is adapted from The Art of Multiprocessor Programming, 12.5.2.
+type Bitonic struct {
+	half   [2]*Bitonic
+	merger *merger
+	width  int
+}
+
+// NewBitonic returns a bitonic network. The width must be a power of two
+// and at least 2.
+func NewBitonic(width int) *Bitonic {
+	checkWidth(width)
+
+	b := &Bitonic{
+		merger: newMerger(width),
+		width:  width,
+	}
+
+	if width > 2 {
+		b.half[0] = NewBitonic(width / 2)
+		b.half[1] = NewBitonic(width / 2)
+	}
+
+	return b
+}
+
+// Width returns the number of input and output wires.
+func (b *Bitonic) Width() int {
+	return b.width
+}
+
+// Traverse sends a token through the network on the given input wire and
+// returns the output wire it left on.
+func (b *Bitonic) Traverse(input int) int {
+	half := b.width / 2
+	subnet := input / half
+
+	output := 0
+	if b.width > 2 {
+		output = b.half[subnet].Traverse(input - subnet*half)
+	}
+
+	return b.merger.traverse(subnet*half + output)
+}
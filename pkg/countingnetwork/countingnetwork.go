@@ -0,0 +1,78 @@
+// Package countingnetwork provides the counting networks from The Art of
+// Multiprocessor Programming, chapter 12.
+//
+// A counting network is built out of balancers wired together so that no
+// matter how tokens enter it, once it is quiescent the number of tokens
+// which left on each output wire satisfies the step property: for i < j,
+// the counts y_i and y_j differ by at most one and y_i >= y_j. A shared
+// counter is built by attaching a local counter to every output wire.
+package countingnetwork
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// Network is a counting network.
+type Network interface {
+	// Width returns the number of input and output wires.
+	Width() int
+
+	// Traverse sends a token through the network on the given input wire
+	// and returns the output wire it left on.
+	Traverse(input int) int
+}
+
+// Balancer is a toggle with one input and two outputs, tokens leave on
+// alternating outputs starting with the top one, 0.
+type Balancer struct {
+	toggle atomic.Uint64
+	_      [56]byte
+}
+
+// Traverse sends a token through the balancer and returns the output it
+// left on.
+func (b *Balancer) Traverse() int {
+	return int((b.toggle.Add(1) - 1) & 1)
+}
+
+// Counter is a shared counter built on a counting network. Output wire i
+// hands out the values i, i+width, i+2*width and so on, so the values
+// are unique and, whenever the network is quiescent, have no gaps.
+type Counter struct {
+	network Network
+	wires   []wire
+}
+
+type wire struct {
+	next atomic.Int64
+	_    [56]byte
+}
+
+func NewCounter(network Network) *Counter {
+	c := &Counter{
+		network: network,
+		wires:   make([]wire, network.Width()),
+	}
+
+	for i := range c.wires {
+		c.wires[i].next.Store(int64(i))
+	}
+
+	return c
+}
+
+// GetAndIncrement increments the counter and returns its prior value. The
+// token enters the network on a random input wire.
+func (c *Counter) GetAndIncrement() int64 {
+	width := int64(len(c.wires))
+	out := c.network.Traverse(rand.Intn(len(c.wires)))
+
+	return c.wires[out].next.Add(width) - width
+}
+
+func checkWidth(width int) {
+	if width < 2 || width&(width-1) != 0 {
+		panic("countingnetwork: width must be a power of two >= 2")
+	}
+}
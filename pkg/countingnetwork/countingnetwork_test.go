@@ -0,0 +1,167 @@
+package countingnetwork
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func networks() map[string]func(width int) Network {
+	return map[string]func(width int) Network{
+		"bitonic": func(width int) Network {
+			return NewBitonic(width)
+		},
+		"periodic": func(width int) Network {
+			return NewPeriodic(width)
+		},
+	}
+}
+
+// step reports whether the counts satisfy the step property.
+func step(counts []int64) bool {
+	for i := range counts {
+		for j := i + 1; j < len(counts); j++ {
+			if d := counts[i] - counts[j]; d < 0 || d > 1 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func Test_Balancer_Traverse(t *testing.T) {
+	var b Balancer
+
+	for i := 0; i < 10; i++ {
+		if got := b.Traverse(); got != i%2 {
+			t.Errorf("got %v, want %v", got, i%2)
+		}
+	}
+}
+
+func Test_Network_Step(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newNetwork := range networks() {
+			for _, width := range []int{2, 4, 8, 16} {
+				t.Run(name, func(t *testing.T) {
+					n := newNetwork(width)
+					counts := make([]int64, width)
+
+					// the network is quiescent after every token
+					for i := 0; i < 1000; i++ {
+						counts[n.Traverse(rand.Intn(width))]++
+
+						if !step(counts) {
+							t.Fatalf("width %v: step property violated: %v", width, counts)
+						}
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		const goroutines, tokens = 64, 1000
+
+		for name, newNetwork := range networks() {
+			for _, width := range []int{2, 4, 8, 16, 32} {
+				t.Run(name, func(t *testing.T) {
+					n := newNetwork(width)
+					counts := make([]atomic.Int64, width)
+
+					var wg sync.WaitGroup
+					for g := 0; g < goroutines; g++ {
+						wg.Add(1)
+						go func(g int) {
+							defer wg.Done()
+
+							// skew the inputs so that the network has to
+							// do the balancing
+							for i := 0; i < tokens; i++ {
+								input := g % width
+								if i%3 == 0 {
+									input = rand.Intn(width)
+								}
+
+								counts[n.Traverse(input)].Add(1)
+							}
+						}(g)
+					}
+					wg.Wait()
+
+					got := make([]int64, width)
+					for i := range counts {
+						got[i] = counts[i].Load()
+					}
+
+					if !step(got) {
+						t.Errorf("width %v: step property violated: %v", width, got)
+					}
+				})
+			}
+		}
+	})
+}
+
+func Test_Counter_GetAndIncrement(t *testing.T) {
+	const goroutines, increments = 32, 500
+
+	for name, newNetwork := range networks() {
+		t.Run(name, func(t *testing.T) {
+			c := NewCounter(newNetwork(8))
+
+			var mu sync.Mutex
+			var got []int64
+
+			var wg sync.WaitGroup
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					values := make([]int64, 0, increments)
+					for j := 0; j < increments; j++ {
+						values = append(values, c.GetAndIncrement())
+					}
+
+					mu.Lock()
+					got = append(got, values...)
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			// quiescent again, so every value up to the total was handed
+			// out exactly once
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			for i, v := range got {
+				if v != int64(i) {
+					t.Fatalf("got %v at %v, want %v", v, i, i)
+				}
+			}
+
+			if len(got) != goroutines*increments {
+				t.Errorf("got %v values, want %v", len(got), goroutines*increments)
+			}
+		})
+	}
+}
+
+func Test_Network_InvalidWidth(t *testing.T) {
+	for name, newNetwork := range networks() {
+		for _, width := range []int{0, 1, 6} {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("width %v did not panic", width)
+					}
+				}()
+
+				newNetwork(width)
+			})
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package countingnetwork
+
+import "math/bits"
+
+// layer connects input i and width-i-1 to the same balancer.
+type layer struct {
+	balancers []*Balancer
+}
+
+func newLayer(width int) *layer {
+	l := &layer{
+		balancers: make([]*Balancer, width),
+	}
+
+	for i := 0; i < width/2; i++ {
+		b := &Balancer{}
+		l.balancers[i], l.balancers[width-i-1] = b, b
+	}
+
+	return l
+}
+
+func (l *layer) traverse(input int) int {
+	width := len(l.balancers)
+
+	lo, hi := input, width-input-1
+	if input >= width/2 {
+		lo, hi = hi, lo
+	}
+
+	if l.balancers[input].Traverse() == 0 {
+		return lo
+	}
+
+	return hi
+}
+
+// block is a layer followed by two blocks of half the width, one on the
+// top and one on the bottom outputs of the layer.
+type block struct {
+	north, south *block
+	layer        *layer
+	width        int
+}
+
+func newBlock(width int) *block {
+	b := &block{
+		layer: newLayer(width),
+		width: width,
+	}
+
+	if width > 2 {
+		b.north = newBlock(width / 2)
+		b.south = newBlock(width / 2)
+	}
+
+	return b
+}
+
+func (b *block) traverse(input int) int {
+	wire := b.layer.traverse(input)
+	if b.width == 2 {
+		return wire
+	}
+
+	if half := b.width / 2; wire >= half {
+		return half + b.south.traverse(wire-half)
+	}
+
+	return b.north.traverse(wire)
+}
+
+// Periodic is the periodic counting network. It is a chain of log width
+// identical blocks, and has depth O(log^2 width) like Bitonic.
+//
+// This is adapted from The Art of Multiprocessor Programming, 12.5.3.
+type Periodic struct {
+	blocks []*block
+	width  int
+}
+
+// NewPeriodic returns a periodic network. The width must be a power of
+// two and at least 2.
+func NewPeriodic(width int) *Periodic {
+	checkWidth(width)
+
+	p := &Periodic{
+		blocks: make([]*block, bits.Len(uint(width))-1),
+		width:  width,
+	}
+
+	for i := range p.blocks {
+		p.blocks[i] = newBlock(width)
+	}
+
+	return p
+}
+
+// Width returns the number of input and output wires.
+func (p *Periodic) Width() int {
+	return p.width
+}
+
+// Traverse sends a token through the network on the given input wire and
+// returns the output wire it left on.
+func (p *Periodic) Traverse(input int) int {
+	wire := input
+	for _, b := range p.blocks {
+		wire = b.traverse(wire)
+	}
+
+	return wire
+}
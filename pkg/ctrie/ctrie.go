@@ -0,0 +1,790 @@
+// Package ctrie provides a concurrent hash trie with O(1) snapshots.
+package ctrie
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+	"github.com/tangledbytes/godc/pkg/hashmap"
+)
+
+const (
+	// w is the number of hash bits consumed per level of the trie
+	w = 6
+
+	hashBits = 64
+)
+
+// generation identifies the snapshot a node belongs to, generations are
+// compared by address only.
+type generation struct {
+	_ byte
+}
+
+type iNode[K comparable, V any] struct {
+	main atomic.Pointer[mainNode[K, V]]
+	gen  *generation
+}
+
+// mainNode is the node an iNode points to. Exactly one of cNode, tNode,
+// lNode and failed is set.
+type mainNode[K comparable, V any] struct {
+	cNode  *cNode[K, V]
+	tNode  *sNode[K, V]
+	lNode  *lNode[K, V]
+	failed *mainNode[K, V]
+
+	// prev is the main node this one replaced while the GCAS which
+	// installed it is still in progress
+	prev atomic.Pointer[mainNode[K, V]]
+}
+
+type cNode[K comparable, V any] struct {
+	bmp   uint64
+	array []branch
+	gen   *generation
+}
+
+// branch is either an *iNode or an *sNode.
+type branch interface{}
+
+type sNode[K comparable, V any] struct {
+	key   K
+	value V
+	hash  uint64
+}
+
+// lNode holds the entries whose hashes fully collide.
+type lNode[K comparable, V any] struct {
+	entries []*sNode[K, V]
+}
+
+type status int
+
+const (
+	notFound status = iota
+	found
+	restart
+)
+
+type rdcssDescriptor[K comparable, V any] struct {
+	old       *iNode[K, V]
+	expected  *mainNode[K, V]
+	nv        *iNode[K, V]
+	committed atomic.Bool
+}
+
+// rootNode is either a plain root or an RDCSS in progress.
+type rootNode[K comparable, V any] struct {
+	in   *iNode[K, V]
+	desc *rdcssDescriptor[K, V]
+}
+
+// Ctrie is a lock-free concurrent hash trie which supports taking
+// consistent snapshots of itself in constant time.
+//
+// Every update is installed with a generation-compare-and-swap (GCAS)
+// which only succeeds if the root still belongs to the generation the
+// update started in. Taking a snapshot swaps in a root of a new
+// generation, after which the trie is lazily copied by whoever touches a
+// node of the old generation next.
+//
+// This is adapted from Prokopec et al., "Concurrent Tries with Efficient
+// Non-Blocking Snapshots".
+type Ctrie[K comparable, V any] struct {
+	root     atomic.Pointer[rootNode[K, V]]
+	hasher   hashmap.Hasher[K]
+	readOnly bool
+}
+
+// Option configures a Ctrie.
+type Option[K comparable] func(*config[K])
+
+type config[K comparable] struct {
+	hasher hashmap.Hasher[K]
+}
+
+// WithHasher sets the hasher used by the trie, it defaults to
+// hashmap.DefaultHasher.
+func WithHasher[K comparable](hasher hashmap.Hasher[K]) Option[K] {
+	return func(c *config[K]) {
+		c.hasher = hasher
+	}
+}
+
+func New[K comparable, V any](opts ...Option[K]) *Ctrie[K, V] {
+	cfg := config[K]{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.hasher == nil {
+		cfg.hasher = hashmap.DefaultHasher[K]()
+	}
+
+	gen := &generation{}
+	root := &iNode[K, V]{gen: gen}
+	root.main.Store(&mainNode[K, V]{cNode: &cNode[K, V]{gen: gen}})
+
+	return newCtrie(root, cfg.hasher, false)
+}
+
+func newCtrie[K comparable, V any](root *iNode[K, V], hasher hashmap.Hasher[K], readOnly bool) *Ctrie[K, V] {
+	c := &Ctrie[K, V]{
+		hasher:   hasher,
+		readOnly: readOnly,
+	}
+	c.root.Store(&rootNode[K, V]{in: root})
+
+	return c
+}
+
+// Load returns the value stored in the trie for a key and reports
+// whether it was present.
+func (c *Ctrie[K, V]) Load(key K) (V, bool) {
+	hash := c.hasher(key)
+	for {
+		root := c.readRoot(false)
+
+		value, res := c.ilookup(root, key, hash, 0, nil, root.gen)
+		if res != restart {
+			return value, res == found
+		}
+	}
+}
+
+// Store sets the value for a key.
+func (c *Ctrie[K, V]) Store(key K, value V) {
+	c.insert(key, value, false)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (c *Ctrie[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	actual, res := c.insert(key, value, true)
+	if res == found {
+		return actual, true
+	}
+
+	return value, false
+}
+
+// Delete deletes the value for a key.
+func (c *Ctrie[K, V]) Delete(key K) {
+	c.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous
+// value if any. The loaded result reports whether the key was present.
+func (c *Ctrie[K, V]) LoadAndDelete(key K) (V, bool) {
+	c.assertWritable()
+
+	hash := c.hasher(key)
+	for {
+		root := c.readRoot(false)
+
+		value, res := c.iremove(root, key, hash, 0, nil, root.gen)
+		if res != restart {
+			return value, res == found
+		}
+	}
+}
+
+// Snapshot returns a writable snapshot of the trie. The snapshot and the
+// trie share their structure and diverge as either of them is modified.
+func (c *Ctrie[K, V]) Snapshot() *Ctrie[K, V] {
+	c.assertWritable()
+
+	for {
+		root := c.readRoot(false)
+		main := c.gcasRead(root)
+
+		if c.rdcssRoot(root, main, c.copyToGen(root, &generation{})) {
+			return newCtrie(c.copyToGen(root, &generation{}), c.hasher, false)
+		}
+	}
+}
+
+// ReadOnlySnapshot returns a snapshot of the trie which panics on
+// writes. It is cheaper than Snapshot as reading it never copies nodes.
+func (c *Ctrie[K, V]) ReadOnlySnapshot() *Ctrie[K, V] {
+	if c.readOnly {
+		return c
+	}
+
+	for {
+		root := c.readRoot(false)
+		main := c.gcasRead(root)
+
+		if c.rdcssRoot(root, main, c.copyToGen(root, &generation{})) {
+			return newCtrie(root, c.hasher, true)
+		}
+	}
+}
+
+// Range calls f sequentially for each key and value present in the trie.
+// If f returns false, Range stops the iteration.
+//
+// Range iterates over a read-only snapshot taken when it is called, so
+// it is linearizable and unaffected by concurrent writers.
+func (c *Ctrie[K, V]) Range(f func(key K, value V) bool) {
+	snapshot := c.ReadOnlySnapshot()
+	snapshot.traverse(snapshot.readRoot(false), f)
+}
+
+// Len returns the number of entries in the trie. It is computed by
+// iterating over a snapshot and so takes linear time.
+func (c *Ctrie[K, V]) Len() int {
+	n := 0
+	c.Range(func(K, V) bool {
+		n++
+		return true
+	})
+
+	return n
+}
+
+// ReadOnly reports whether the trie is a read-only snapshot.
+func (c *Ctrie[K, V]) ReadOnly() bool {
+	return c.readOnly
+}
+
+func (c *Ctrie[K, V]) assertWritable() {
+	if c.readOnly {
+		panic("ctrie: write to a read-only snapshot")
+	}
+}
+
+func (c *Ctrie[K, V]) insert(key K, value V, onlyIfAbsent bool) (V, status) {
+	c.assertWritable()
+
+	hash := c.hasher(key)
+	sn := &sNode[K, V]{key: key, value: value, hash: hash}
+
+	for {
+		root := c.readRoot(false)
+
+		actual, res := c.iinsert(root, sn, 0, nil, root.gen, onlyIfAbsent)
+		if res != restart {
+			return actual, res
+		}
+	}
+}
+
+func (c *Ctrie[K, V]) traverse(in *iNode[K, V], f func(key K, value V) bool) bool {
+	main := c.gcasRead(in)
+
+	switch {
+	case main.cNode != nil:
+		for _, br := range main.cNode.array {
+			switch br := br.(type) {
+			case *iNode[K, V]:
+				if !c.traverse(br, f) {
+					return false
+				}
+			case *sNode[K, V]:
+				if !f(br.key, br.value) {
+					return false
+				}
+			}
+		}
+	case main.tNode != nil:
+		return f(main.tNode.key, main.tNode.value)
+	case main.lNode != nil:
+		for _, sn := range main.lNode.entries {
+			if !f(sn.key, sn.value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (c *Ctrie[K, V]) ilookup(in *iNode[K, V], key K, hash uint64, lev uint, parent *iNode[K, V], startGen *generation) (V, status) {
+	var def V
+
+	main := c.gcasRead(in)
+
+	switch {
+	case main.cNode != nil:
+		cn := main.cNode
+		flag, pos := flagPos(hash, lev, cn.bmp)
+		if cn.bmp&flag == 0 {
+			return def, notFound
+		}
+
+		switch br := cn.array[pos].(type) {
+		case *iNode[K, V]:
+			if c.readOnly || startGen == br.gen {
+				return c.ilookup(br, key, hash, lev+w, in, startGen)
+			}
+
+			// the branch belongs to an older generation - copy it into
+			// ours before descending
+			if c.gcas(in, main, &mainNode[K, V]{cNode: c.renewed(cn, startGen)}) {
+				return c.ilookup(in, key, hash, lev, parent, startGen)
+			}
+			return def, restart
+		case *sNode[K, V]:
+			if br.hash == hash && br.key == key {
+				return br.value, found
+			}
+			return def, notFound
+		}
+	case main.tNode != nil:
+		if c.readOnly {
+			if main.tNode.hash == hash && main.tNode.key == key {
+				return main.tNode.value, found
+			}
+			return def, notFound
+		}
+
+		c.clean(parent, lev-w)
+		return def, restart
+	case main.lNode != nil:
+		if sn := main.lNode.lookup(key); sn != nil {
+			return sn.value, found
+		}
+		return def, notFound
+	}
+
+	panic("ctrie: unreachable")
+}
+
+func (c *Ctrie[K, V]) iinsert(in *iNode[K, V], sn *sNode[K, V], lev uint, parent *iNode[K, V], startGen *generation, onlyIfAbsent bool) (V, status) {
+	var def V
+
+	main := c.gcasRead(in)
+
+	switch {
+	case main.cNode != nil:
+		cn := main.cNode
+		flag, pos := flagPos(sn.hash, lev, cn.bmp)
+
+		rn := cn
+		if cn.gen != in.gen {
+			rn = c.renewed(cn, in.gen)
+		}
+
+		if cn.bmp&flag == 0 {
+			if c.gcas(in, main, &mainNode[K, V]{cNode: rn.inserted(pos, flag, sn, in.gen)}) {
+				return def, notFound
+			}
+			return def, restart
+		}
+
+		switch br := cn.array[pos].(type) {
+		case *iNode[K, V]:
+			if startGen == br.gen {
+				return c.iinsert(br, sn, lev+w, in, startGen, onlyIfAbsent)
+			}
+
+			if c.gcas(in, main, &mainNode[K, V]{cNode: c.renewed(cn, startGen)}) {
+				return c.iinsert(in, sn, lev, parent, startGen, onlyIfAbsent)
+			}
+			return def, restart
+		case *sNode[K, V]:
+			if br.hash == sn.hash && br.key == sn.key {
+				if onlyIfAbsent {
+					return br.value, found
+				}
+
+				if c.gcas(in, main, &mainNode[K, V]{cNode: rn.updated(pos, sn, in.gen)}) {
+					return br.value, found
+				}
+				return def, restart
+			}
+
+			// push both the entries one level down
+			sub := &iNode[K, V]{gen: in.gen}
+			sub.main.Store(dual(br, sn, lev+w, in.gen))
+
+			if c.gcas(in, main, &mainNode[K, V]{cNode: rn.updated(pos, sub, in.gen)}) {
+				return def, notFound
+			}
+			return def, restart
+		}
+	case main.tNode != nil:
+		c.clean(parent, lev-w)
+		return def, restart
+	case main.lNode != nil:
+		if old := main.lNode.lookup(sn.key); old != nil && onlyIfAbsent {
+			return old.value, found
+		}
+
+		if c.gcas(in, main, &mainNode[K, V]{lNode: main.lNode.inserted(sn)}) {
+			return def, notFound
+		}
+		return def, restart
+	}
+
+	panic("ctrie: unreachable")
+}
+
+func (c *Ctrie[K, V]) iremove(in *iNode[K, V], key K, hash uint64, lev uint, parent *iNode[K, V], startGen *generation) (V, status) {
+	var def V
+
+	main := c.gcasRead(in)
+
+	switch {
+	case main.cNode != nil:
+		cn := main.cNode
+		flag, pos := flagPos(hash, lev, cn.bmp)
+		if cn.bmp&flag == 0 {
+			return def, notFound
+		}
+
+		var value V
+		var res status
+
+		switch br := cn.array[pos].(type) {
+		case *iNode[K, V]:
+			if startGen == br.gen {
+				value, res = c.iremove(br, key, hash, lev+w, in, startGen)
+			} else if c.gcas(in, main, &mainNode[K, V]{cNode: c.renewed(cn, startGen)}) {
+				value, res = c.iremove(in, key, hash, lev, parent, startGen)
+			} else {
+				res = restart
+			}
+		case *sNode[K, V]:
+			if br.hash != hash || br.key != key {
+				return def, notFound
+			}
+
+			ncn := cn.removed(pos, flag, in.gen)
+			if c.gcas(in, main, toContracted(ncn, lev)) {
+				value, res = br.value, found
+			} else {
+				res = restart
+			}
+		}
+
+		if res != found {
+			return value, res
+		}
+
+		// if we left behind a tomb then resurrect its entry into the
+		// parent
+		if parent != nil && c.gcasRead(in).tNode != nil {
+			c.cleanParent(parent, in, hash, lev-w, startGen)
+		}
+
+		return value, res
+	case main.tNode != nil:
+		c.clean(parent, lev-w)
+		return def, restart
+	case main.lNode != nil:
+		sn := main.lNode.lookup(key)
+		if sn == nil {
+			return def, notFound
+		}
+
+		nln := main.lNode.removed(key)
+
+		nmain := &mainNode[K, V]{lNode: nln}
+		if len(nln.entries) == 1 {
+			nmain = &mainNode[K, V]{tNode: nln.entries[0]}
+		}
+
+		if c.gcas(in, main, nmain) {
+			return sn.value, found
+		}
+		return def, restart
+	}
+
+	panic("ctrie: unreachable")
+}
+
+// clean compresses the cNode of in, resurrecting the tombs below it.
+func (c *Ctrie[K, V]) clean(in *iNode[K, V], lev uint) {
+	main := c.gcasRead(in)
+	if main.cNode != nil {
+		c.gcas(in, main, c.toCompressed(main.cNode, lev, in.gen))
+	}
+}
+
+func (c *Ctrie[K, V]) cleanParent(parent, in *iNode[K, V], hash uint64, lev uint, startGen *generation) {
+	for {
+		main := c.gcasRead(in)
+		pmain := c.gcasRead(parent)
+		if pmain.cNode == nil {
+			return
+		}
+
+		cn := pmain.cNode
+		flag, pos := flagPos(hash, lev, cn.bmp)
+		if cn.bmp&flag == 0 || cn.array[pos] != branch(in) || main.tNode == nil {
+			return
+		}
+
+		ncn := cn.updated(pos, main.tNode, in.gen)
+		if c.gcas(parent, pmain, toContracted(ncn, lev)) {
+			return
+		}
+
+		if c.readRoot(false).gen != startGen {
+			return
+		}
+	}
+}
+
+// renewed returns a copy of cn with all of its iNodes copied to gen.
+func (c *Ctrie[K, V]) renewed(cn *cNode[K, V], gen *generation) *cNode[K, V] {
+	array := make([]branch, len(cn.array))
+	for i, br := range cn.array {
+		if in, ok := br.(*iNode[K, V]); ok {
+			array[i] = c.copyToGen(in, gen)
+		} else {
+			array[i] = br
+		}
+	}
+
+	return &cNode[K, V]{bmp: cn.bmp, array: array, gen: gen}
+}
+
+func (c *Ctrie[K, V]) copyToGen(in *iNode[K, V], gen *generation) *iNode[K, V] {
+	nin := &iNode[K, V]{gen: gen}
+	nin.main.Store(c.gcasRead(in))
+
+	return nin
+}
+
+// toCompressed returns a copy of cn where the iNodes holding a tomb are
+// replaced with the entry of the tomb.
+func (c *Ctrie[K, V]) toCompressed(cn *cNode[K, V], lev uint, gen *generation) *mainNode[K, V] {
+	array := make([]branch, len(cn.array))
+	for i, br := range cn.array {
+		array[i] = br
+		if in, ok := br.(*iNode[K, V]); ok {
+			if main := c.gcasRead(in); main.tNode != nil {
+				array[i] = main.tNode
+			}
+		}
+	}
+
+	return toContracted(&cNode[K, V]{bmp: cn.bmp, array: array, gen: gen}, lev)
+}
+
+// gcas replaces the main node of in from old to n if the root of the trie
+// is still of the generation of in, which is decided by gcasComplete.
+func (c *Ctrie[K, V]) gcas(in *iNode[K, V], old, n *mainNode[K, V]) bool {
+	n.prev.Store(old)
+	sched.Yield("ctrie: gcas loaded main node")
+
+	if in.main.CompareAndSwap(old, n) {
+		sched.Yield("ctrie: gcas swapped main node")
+		c.gcasComplete(in, n)
+		return n.prev.Load() == nil
+	}
+
+	return false
+}
+
+func (c *Ctrie[K, V]) gcasRead(in *iNode[K, V]) *mainNode[K, V] {
+	main := in.main.Load()
+	if main.prev.Load() == nil {
+		return main
+	}
+
+	return c.gcasComplete(in, main)
+}
+
+func (c *Ctrie[K, V]) gcasComplete(in *iNode[K, V], main *mainNode[K, V]) *mainNode[K, V] {
+	for {
+		if main == nil {
+			return nil
+		}
+
+		prev := main.prev.Load()
+		root := c.readRoot(true)
+		if prev == nil {
+			return main
+		}
+		sched.Yield("ctrie: gcas complete loaded prev")
+
+		if prev.failed != nil {
+			// the GCAS failed - roll back to the previous main node
+			if in.main.CompareAndSwap(main, prev.failed) {
+				return prev.failed
+			}
+
+			main = in.main.Load()
+			continue
+		}
+
+		if root.gen == in.gen && !c.readOnly {
+			// commit
+			if main.prev.CompareAndSwap(prev, nil) {
+				return main
+			}
+			continue
+		}
+
+		// a snapshot was taken in between, abort
+		main.prev.CompareAndSwap(prev, &mainNode[K, V]{failed: prev})
+		main = in.main.Load()
+	}
+}
+
+func (c *Ctrie[K, V]) readRoot(abort bool) *iNode[K, V] {
+	root := c.root.Load()
+	if root.desc == nil {
+		return root.in
+	}
+
+	return c.rdcssComplete(abort)
+}
+
+// rdcssRoot replaces the root ov with nv if the main node of ov is still
+// expected, as a restricted double compare single swap.
+func (c *Ctrie[K, V]) rdcssRoot(ov *iNode[K, V], expected *mainNode[K, V], nv *iNode[K, V]) bool {
+	root := c.root.Load()
+	if root.desc != nil || root.in != ov {
+		return false
+	}
+
+	desc := &rdcssDescriptor[K, V]{old: ov, expected: expected, nv: nv}
+	sched.Yield("ctrie: rdcss loaded root")
+
+	if !c.root.CompareAndSwap(root, &rootNode[K, V]{desc: desc}) {
+		return false
+	}
+	sched.Yield("ctrie: rdcss installed descriptor")
+
+	c.rdcssComplete(false)
+	return desc.committed.Load()
+}
+
+func (c *Ctrie[K, V]) rdcssComplete(abort bool) *iNode[K, V] {
+	for {
+		root := c.root.Load()
+		if root.desc == nil {
+			return root.in
+		}
+
+		desc := root.desc
+		sched.Yield("ctrie: rdcss complete loaded root")
+
+		if abort {
+			if c.root.CompareAndSwap(root, &rootNode[K, V]{in: desc.old}) {
+				return desc.old
+			}
+			continue
+		}
+
+		if c.gcasRead(desc.old) == desc.expected {
+			if c.root.CompareAndSwap(root, &rootNode[K, V]{in: desc.nv}) {
+				desc.committed.Store(true)
+				return desc.nv
+			}
+			continue
+		}
+
+		if c.root.CompareAndSwap(root, &rootNode[K, V]{in: desc.old}) {
+			return desc.old
+		}
+	}
+}
+
+func (cn *cNode[K, V]) inserted(pos int, flag uint64, br branch, gen *generation) *cNode[K, V] {
+	array := make([]branch, len(cn.array)+1)
+	copy(array, cn.array[:pos])
+	array[pos] = br
+	copy(array[pos+1:], cn.array[pos:])
+
+	return &cNode[K, V]{bmp: cn.bmp | flag, array: array, gen: gen}
+}
+
+func (cn *cNode[K, V]) updated(pos int, br branch, gen *generation) *cNode[K, V] {
+	array := make([]branch, len(cn.array))
+	copy(array, cn.array)
+	array[pos] = br
+
+	return &cNode[K, V]{bmp: cn.bmp, array: array, gen: gen}
+}
+
+func (cn *cNode[K, V]) removed(pos int, flag uint64, gen *generation) *cNode[K, V] {
+	array := make([]branch, len(cn.array)-1)
+	copy(array, cn.array[:pos])
+	copy(array[pos:], cn.array[pos+1:])
+
+	return &cNode[K, V]{bmp: cn.bmp ^ flag, array: array, gen: gen}
+}
+
+// toContracted entombs the only entry of a cNode below the root so that
+// it can be pulled up into the parent.
+func toContracted[K comparable, V any](cn *cNode[K, V], lev uint) *mainNode[K, V] {
+	if lev > 0 && len(cn.array) == 1 {
+		if sn, ok := cn.array[0].(*sNode[K, V]); ok {
+			return &mainNode[K, V]{tNode: sn}
+		}
+	}
+
+	return &mainNode[K, V]{cNode: cn}
+}
+
+// dual returns the main node holding x and y below level lev.
+func dual[K comparable, V any](x, y *sNode[K, V], lev uint, gen *generation) *mainNode[K, V] {
+	if lev >= hashBits {
+		return &mainNode[K, V]{lNode: &lNode[K, V]{entries: []*sNode[K, V]{x, y}}}
+	}
+
+	xi, yi := (x.hash>>lev)&(1<<w-1), (y.hash>>lev)&(1<<w-1)
+	bmp := uint64(1)<<xi | uint64(1)<<yi
+
+	if xi == yi {
+		sub := &iNode[K, V]{gen: gen}
+		sub.main.Store(dual(x, y, lev+w, gen))
+
+		return &mainNode[K, V]{cNode: &cNode[K, V]{bmp: bmp, array: []branch{sub}, gen: gen}}
+	}
+
+	array := []branch{x, y}
+	if xi > yi {
+		array = []branch{y, x}
+	}
+
+	return &mainNode[K, V]{cNode: &cNode[K, V]{bmp: bmp, array: array, gen: gen}}
+}
+
+// flagPos returns the bit of hash at level lev in a cNode bitmap and the
+// position of the corresponding branch in its array.
+func flagPos(hash uint64, lev uint, bmp uint64) (uint64, int) {
+	flag := uint64(1) << ((hash >> lev) & (1<<w - 1))
+	return flag, bits.OnesCount64(bmp & (flag - 1))
+}
+
+func (ln *lNode[K, V]) lookup(key K) *sNode[K, V] {
+	for _, sn := range ln.entries {
+		if sn.key == key {
+			return sn
+		}
+	}
+
+	return nil
+}
+
+func (ln *lNode[K, V]) inserted(sn *sNode[K, V]) *lNode[K, V] {
+	entries := make([]*sNode[K, V], 0, len(ln.entries)+1)
+	for _, e := range ln.entries {
+		if e.key != sn.key {
+			entries = append(entries, e)
+		}
+	}
+
+	return &lNode[K, V]{entries: append(entries, sn)}
+}
+
+func (ln *lNode[K, V]) removed(key K) *lNode[K, V] {
+	entries := make([]*sNode[K, V], 0, len(ln.entries))
+	for _, e := range ln.entries {
+		if e.key != key {
+			entries = append(entries, e)
+		}
+	}
+
+	return &lNode[K, V]{entries: entries}
+}
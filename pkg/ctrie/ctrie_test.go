@@ -0,0 +1,311 @@
+package ctrie_test
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/util"
+	"github.com/tangledbytes/godc/pkg/ctrie"
+	"github.com/tangledbytes/godc/pkg/hashmap"
+)
+
+var _ hashmap.Map[int, int] = (*ctrie.Ctrie[int, int])(nil)
+
+func hashers() map[string]hashmap.Hasher[int] {
+	return map[string]hashmap.Hasher[int]{
+		"default": hashmap.DefaultHasher[int](),
+		// forces entries into deep levels and collision lists
+		"colliding": func(key int) uint64 {
+			return uint64(key % 4)
+		},
+	}
+}
+
+func Test_Ctrie_Store(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		type test struct {
+			name string
+			data []int
+			want []int
+		}
+
+		tests := []test{
+			{
+				name: "empty",
+				data: []int{},
+				want: []int{},
+			},
+			{
+				name: "single element",
+				data: []int{1},
+				want: []int{1},
+			},
+			{
+				name: "overwrite",
+				data: []int{1, 1, 2, 1},
+				want: []int{1, 2},
+			},
+			{
+				name: "multiple elements",
+				data: util.GenerateRandomIntSeries(1, 1000),
+				want: util.GenerateIntSeries(1, 1000),
+			},
+		}
+
+		for name, hasher := range hashers() {
+			for _, tt := range tests {
+				t.Run(name+" - "+tt.name, func(t *testing.T) {
+					c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+
+					for _, data := range tt.data {
+						c.Store(data, data*10)
+					}
+
+					for _, data := range tt.want {
+						if got, ok := c.Load(data); got != data*10 || !ok {
+							t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{data * 10, true})
+						}
+					}
+
+					if got := keys(c); !equal(got, tt.want) {
+						t.Errorf("got %v, want %v", got, tt.want)
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, hasher := range hashers() {
+			t.Run(name, func(t *testing.T) {
+				c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+				data := util.GenerateIntSeries(1, 1000)
+
+				var wg sync.WaitGroup
+				for _, d := range data {
+					wg.Add(1)
+					go func(d int) {
+						c.Store(d, d)
+						wg.Done()
+					}(d)
+				}
+				wg.Wait()
+
+				if got := keys(c); !equal(got, data) {
+					t.Errorf("got %v, want %v", got, data)
+				}
+			})
+		}
+	})
+}
+
+func Test_Ctrie_LoadOrStore(t *testing.T) {
+	for name, hasher := range hashers() {
+		t.Run(name, func(t *testing.T) {
+			c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+
+			for _, k := range []int{1, 5, 9} {
+				if got, loaded := c.LoadOrStore(k, 10); got != 10 || loaded {
+					t.Errorf("got %v, want %v", [2]interface{}{got, loaded}, [2]interface{}{10, false})
+				}
+
+				if got, loaded := c.LoadOrStore(k, 20); got != 10 || !loaded {
+					t.Errorf("got %v, want %v", [2]interface{}{got, loaded}, [2]interface{}{10, true})
+				}
+			}
+		})
+	}
+}
+
+func Test_Ctrie_Delete(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, hasher := range hashers() {
+			t.Run(name, func(t *testing.T) {
+				c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+
+				for _, d := range util.GenerateIntSeries(1, 1000) {
+					c.Store(d, d)
+				}
+
+				for _, d := range util.GenerateRandomIntSeries(1, 800) {
+					if got, ok := c.LoadAndDelete(d); got != d || !ok {
+						t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{d, true})
+					}
+
+					if got, ok := c.LoadAndDelete(d); ok {
+						t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{0, false})
+					}
+				}
+
+				want := util.GenerateIntSeries(801, 1000)
+				if got := keys(c); !equal(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, hasher := range hashers() {
+			t.Run(name, func(t *testing.T) {
+				c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+
+				for _, d := range util.GenerateIntSeries(1, 1000) {
+					c.Store(d, d)
+				}
+
+				var wg sync.WaitGroup
+				for _, d := range util.GenerateIntSeries(1, 500) {
+					wg.Add(2)
+					go func(d int) {
+						c.Delete(d)
+						wg.Done()
+					}(d)
+
+					go func(d int) {
+						c.Store(d+1000, d+1000)
+						wg.Done()
+					}(d)
+				}
+				wg.Wait()
+
+				want := util.GenerateIntSeries(501, 1500)
+				if got := keys(c); !equal(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func Test_Ctrie_Snapshot(t *testing.T) {
+	for name, hasher := range hashers() {
+		t.Run(name, func(t *testing.T) {
+			c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+			for _, d := range util.GenerateIntSeries(1, 100) {
+				c.Store(d, d)
+			}
+
+			snap := c.Snapshot()
+			ro := c.ReadOnlySnapshot()
+
+			// the snapshots must not see changes made to the original
+			for _, d := range util.GenerateIntSeries(1, 50) {
+				c.Delete(d)
+			}
+			c.Store(1000, 1000)
+			c.Store(60, -60)
+
+			// and the original must not see changes made to the snapshot
+			snap.Store(2000, 2000)
+			snap.Delete(100)
+
+			if got, want := keys(c), append(util.GenerateIntSeries(51, 100), 1000); !equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			if got, want := keys(snap), append(util.GenerateIntSeries(1, 99), 2000); !equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			if got, want := keys(ro), util.GenerateIntSeries(1, 100); !equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			if got, _ := ro.Load(60); got != 60 {
+				t.Errorf("got %v, want %v", got, 60)
+			}
+
+			if got, _ := c.Load(60); got != -60 {
+				t.Errorf("got %v, want %v", got, -60)
+			}
+		})
+	}
+}
+
+func Test_Ctrie_ReadOnlySnapshot_Write(t *testing.T) {
+	ro := ctrie.New[int, int]().ReadOnlySnapshot()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("write to a read-only snapshot did not panic")
+		}
+	}()
+
+	ro.Store(1, 1)
+}
+
+func Test_Ctrie_Range(t *testing.T) {
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, hasher := range hashers() {
+			t.Run(name, func(t *testing.T) {
+				c := ctrie.New[int, int](ctrie.WithHasher(hasher))
+
+				const window = 100
+
+				// the writer slides a window of keys over the integers, so
+				// any consistent view of the trie is a contiguous range of
+				// at most window+1 keys
+				var done atomic.Bool
+				var wg sync.WaitGroup
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					for i := 0; i < 5000; i++ {
+						c.Store(i, i)
+						if i >= window {
+							c.Delete(i - window)
+						}
+					}
+					done.Store(true)
+				}()
+
+				for !done.Load() {
+					got := keys(c)
+					if len(got) == 0 {
+						continue
+					}
+
+					sort.Ints(got)
+					if len(got) > window+1 || got[len(got)-1]-got[0] != len(got)-1 {
+						t.Fatalf("inconsistent view: %v", got)
+					}
+				}
+
+				wg.Wait()
+			})
+		}
+	})
+}
+
+func keys(c *ctrie.Ctrie[int, int]) []int {
+	got := []int{}
+	c.Range(func(key, _ int) bool {
+		got = append(got, key)
+		return true
+	})
+
+	return got
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]int(nil), a...)
+	b = append([]int(nil), b...)
+	sort.Ints(a)
+	sort.Ints(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,485 @@
+package hashmap
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+const (
+	// cuckooSlots is the number of entries held by a bucket.
+	cuckooSlots = 4
+
+	// cuckooMaxPath is the longest displacement chain attempted before
+	// the table is resized.
+	cuckooMaxPath = 64
+
+	cuckooStripes = 256
+	cuckooInitial = 16
+)
+
+type cuckooEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	h1, h2 uint64
+}
+
+func (e *cuckooEntry[K, V]) entryKey() K {
+	return e.key
+}
+
+type cuckooBucket[K comparable, V any] struct {
+	slots [cuckooSlots]atomic.Pointer[cuckooEntry[K, V]]
+}
+
+type cuckooTable[K comparable, V any] struct {
+	buckets []cuckooBucket[K, V]
+	mask    uint64
+
+	overflow overflow[K, *cuckooEntry[K, V]]
+}
+
+func newCuckooTable[K comparable, V any](size int) *cuckooTable[K, V] {
+	return &cuckooTable[K, V]{
+		buckets: make([]cuckooBucket[K, V], size),
+		mask:    uint64(size - 1),
+	}
+}
+
+func (t *cuckooTable[K, V]) find(b1, b2 uint64, key K) *atomic.Pointer[cuckooEntry[K, V]] {
+	for _, b := range [2]uint64{b1, b2} {
+		for i := range t.buckets[b].slots {
+			if e := t.buckets[b].slots[i].Load(); e != nil && e.key == key {
+				return &t.buckets[b].slots[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *cuckooTable[K, V]) free(b uint64) int {
+	for i := range t.buckets[b].slots {
+		if t.buckets[b].slots[i].Load() == nil {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// alt returns the other bucket e may live in.
+func (t *cuckooTable[K, V]) alt(e *cuckooEntry[K, V], b uint64) uint64 {
+	if b1 := e.h1 & t.mask; b1 != b {
+		return b1
+	}
+
+	return e.h2 & t.mask
+}
+
+// place inserts e kicking out entries as needed. It is only used on
+// tables that are not yet published. If no free slot is found within
+// cuckooMaxPath displacements it returns the entry left without a slot,
+// which need not be e, and otherwise nil.
+func (t *cuckooTable[K, V]) place(e *cuckooEntry[K, V]) *cuckooEntry[K, V] {
+	b := e.h1 & t.mask
+	for i := 0; i < cuckooMaxPath; i++ {
+		if s := t.free(b); s >= 0 {
+			t.buckets[b].slots[s].Store(e)
+			return nil
+		}
+
+		if alt := t.alt(e, b); i == 0 && t.free(alt) >= 0 {
+			b = alt
+			continue
+		}
+
+		victim := t.buckets[b].slots[rand.Intn(cuckooSlots)].Swap(e)
+		e = victim
+		b = t.alt(e, b)
+	}
+
+	return e
+}
+
+// cuckooStripe guards the buckets mapped to it. The version is odd while
+// an entry is being moved between buckets, readers use it to detect that
+// they might have missed an entry.
+type cuckooStripe struct {
+	mu      sync.Mutex
+	version atomic.Uint64
+	_       [48]byte
+}
+
+type cuckooMove[K comparable, V any] struct {
+	bucket uint64
+	slot   int
+	entry  *cuckooEntry[K, V]
+}
+
+// Cuckoo is a concurrent cuckoo hash map. Every key lives in one of two
+// buckets chosen by two independent hash functions, so a lookup probes
+// at most 2 * cuckooSlots entries.
+//
+// Lookups are optimistic and take no locks, writers lock the stripes of
+// both the buckets. When both the buckets are full an entry is displaced
+// along a cuckoo path, one locked move at a time, in the fashion of
+// libcuckoo. When no path is found within cuckooMaxPath moves the table
+// is doubled if it is at least half full. Otherwise too many keys share
+// the same buckets, which happens when the hashers are not independent
+// and which a bigger table would not fix, so the entry goes to a small
+// locked overflow list instead.
+type Cuckoo[K comparable, V any] struct {
+	hasher  Hasher[K]
+	alt     Hasher[K]
+	table   atomic.Pointer[cuckooTable[K, V]]
+	stripes []cuckooStripe
+	count   atomic.Int64
+}
+
+func NewCuckoo[K comparable, V any](opts ...Option[K]) *Cuckoo[K, V] {
+	cfg := newConfig(opts)
+
+	m := &Cuckoo[K, V]{
+		hasher:  cfg.hasher,
+		alt:     cfg.alt,
+		stripes: make([]cuckooStripe, cuckooStripes),
+	}
+	m.table.Store(newCuckooTable[K, V](cuckooInitial))
+
+	return m
+}
+
+// Load returns the value stored in the map for a key and reports
+// whether it was present.
+func (m *Cuckoo[K, V]) Load(key K) (V, bool) {
+	var def V
+
+	h1, h2 := m.hasher(key), m.alt(key)
+	for {
+		t := m.table.Load()
+		b1, b2 := h1&t.mask, h2&t.mask
+		s1, s2 := m.stripe(b1), m.stripe(b2)
+
+		v1, v2 := s1.version.Load(), s2.version.Load()
+		if v1&1 == 1 || v2&1 == 1 {
+			// an entry is being moved
+			runtime.Gosched()
+			continue
+		}
+
+		var e *cuckooEntry[K, V]
+		if slot := t.find(b1, b2, key); slot != nil {
+			e = slot.Load()
+		}
+		if e == nil {
+			e, _ = t.overflow.load(key)
+		}
+		sched.Yield("hashmap: cuckoo load read entry")
+
+		// retry if an entry was moved or the table got replaced while
+		// we were looking
+		if s1.version.Load() != v1 || s2.version.Load() != v2 || m.table.Load() != t {
+			continue
+		}
+
+		if e == nil {
+			return def, false
+		}
+
+		return e.value, true
+	}
+}
+
+// Store sets the value for a key.
+func (m *Cuckoo[K, V]) Store(key K, value V) {
+	m.put(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (m *Cuckoo[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return m.put(key, value, false)
+}
+
+// Delete deletes the value for a key.
+func (m *Cuckoo[K, V]) Delete(key K) {
+	h1, h2 := m.hasher(key), m.alt(key)
+	for {
+		t := m.table.Load()
+		b1, b2 := h1&t.mask, h2&t.mask
+
+		m.lock(b1, b2)
+		if m.table.Load() != t {
+			m.unlock(b1, b2)
+			continue
+		}
+
+		if slot := t.find(b1, b2, key); slot != nil {
+			slot.Store(nil)
+			m.count.Add(-1)
+		} else {
+			t.overflow.mu.Lock()
+			if i := t.overflow.find(key); i >= 0 {
+				t.overflow.remove(i)
+				m.count.Add(-1)
+			}
+			t.overflow.mu.Unlock()
+		}
+
+		m.unlock(b1, b2)
+		return
+	}
+}
+
+// Range calls f sequentially for each key and value present in the
+// map. If f returns false, Range stops the iteration.
+//
+// Range briefly locks the whole map to copy its entries so the iteration
+// is over a consistent snapshot, f is called without holding any lock.
+func (m *Cuckoo[K, V]) Range(f func(key K, value V) bool) {
+	m.lockAll()
+	t := m.table.Load()
+
+	entries := make([]*cuckooEntry[K, V], 0, m.count.Load())
+	for b := range t.buckets {
+		for i := range t.buckets[b].slots {
+			if e := t.buckets[b].slots[i].Load(); e != nil {
+				entries = append(entries, e)
+			}
+		}
+	}
+	t.overflow.mu.Lock()
+	entries = append(entries, t.overflow.entries...)
+	t.overflow.mu.Unlock()
+	m.unlockAll()
+
+	for _, e := range entries {
+		if !f(e.key, e.value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of items in the map.
+func (m *Cuckoo[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+func (m *Cuckoo[K, V]) put(key K, value V, overwrite bool) (V, bool) {
+	h1, h2 := m.hasher(key), m.alt(key)
+	e := &cuckooEntry[K, V]{key: key, value: value, h1: h1, h2: h2}
+
+	// crowded is the table in which no slot could be made for the key
+	var crowded *cuckooTable[K, V]
+	for {
+		t := m.table.Load()
+		b1, b2 := h1&t.mask, h2&t.mask
+
+		m.lock(b1, b2)
+		if m.table.Load() != t {
+			m.unlock(b1, b2)
+			continue
+		}
+
+		if slot := t.find(b1, b2, key); slot != nil {
+			actual := slot.Load().value
+			if overwrite {
+				slot.Store(e)
+			}
+
+			m.unlock(b1, b2)
+			return actual, true
+		}
+
+		t.overflow.mu.Lock()
+		if i := t.overflow.find(key); i >= 0 {
+			actual := t.overflow.entries[i].value
+			if overwrite {
+				t.overflow.entries[i] = e
+			}
+
+			t.overflow.mu.Unlock()
+			m.unlock(b1, b2)
+			return actual, true
+		}
+
+		t.overflow.mu.Unlock()
+
+		for _, b := range [2]uint64{b1, b2} {
+			if s := t.free(b); s >= 0 {
+				t.buckets[b].slots[s].Store(e)
+				m.count.Add(1)
+
+				m.unlock(b1, b2)
+				return value, false
+			}
+		}
+
+		if crowded == t {
+			t.overflow.mu.Lock()
+			t.overflow.add(e)
+			t.overflow.mu.Unlock()
+			m.count.Add(1)
+
+			m.unlock(b1, b2)
+			return value, false
+		}
+		m.unlock(b1, b2)
+
+		// both the buckets are full
+		switch {
+		case m.displace(t, b1, b2):
+		case m.count.Load() >= int64(len(t.buckets)*cuckooSlots/2):
+			m.resize(t)
+		default:
+			crowded = t
+		}
+	}
+}
+
+// displace tries to free a slot in b1 or b2 by moving entries along a
+// cuckoo path. The path is searched without locks and then executed from
+// its free end backwards, each move validated under the locks of the two
+// buckets it touches. It returns false if no path short enough exists.
+func (m *Cuckoo[K, V]) displace(t *cuckooTable[K, V], b1, b2 uint64) bool {
+	b := b1
+	if rand.Intn(2) == 0 {
+		b = b2
+	}
+
+	var path []cuckooMove[K, V]
+	to := cuckooMove[K, V]{slot: -1}
+	for len(path) < cuckooMaxPath {
+		if s := t.free(b); s >= 0 {
+			to = cuckooMove[K, V]{bucket: b, slot: s}
+			break
+		}
+
+		s := rand.Intn(cuckooSlots)
+		e := t.buckets[b].slots[s].Load()
+		if e == nil {
+			continue
+		}
+
+		path = append(path, cuckooMove[K, V]{bucket: b, slot: s, entry: e})
+		b = t.alt(e, b)
+	}
+
+	if to.slot < 0 {
+		return false
+	}
+	sched.Yield("hashmap: cuckoo found path")
+
+	for i := len(path) - 1; i >= 0; i-- {
+		from := path[i]
+
+		m.lock(from.bucket, to.bucket)
+		src := &t.buckets[from.bucket].slots[from.slot]
+		dst := &t.buckets[to.bucket].slots[to.slot]
+
+		// the path went stale - let the caller retry
+		if m.table.Load() != t || src.Load() != from.entry || dst.Load() != nil {
+			m.unlock(from.bucket, to.bucket)
+			return true
+		}
+
+		m.bump(from.bucket, to.bucket)
+		dst.Store(from.entry)
+		src.Store(nil)
+		m.bump(from.bucket, to.bucket)
+
+		m.unlock(from.bucket, to.bucket)
+		to = from
+	}
+
+	return true
+}
+
+// resize doubles the table, the entries which do not fit in it go to its
+// overflow list.
+func (m *Cuckoo[K, V]) resize(t *cuckooTable[K, V]) {
+	m.lockAll()
+	defer m.unlockAll()
+
+	// someone beat us to it
+	if m.table.Load() != t {
+		return
+	}
+
+	fresh := newCuckooTable[K, V](2 * len(t.buckets))
+	move := func(e *cuckooEntry[K, V]) {
+		if homeless := fresh.place(e); homeless != nil {
+			fresh.overflow.add(homeless)
+		}
+	}
+
+	for b := range t.buckets {
+		for i := range t.buckets[b].slots {
+			if e := t.buckets[b].slots[i].Load(); e != nil {
+				move(e)
+			}
+		}
+	}
+
+	// every writer of t is locked out, the list cannot change
+	for _, e := range t.overflow.entries {
+		move(e)
+	}
+
+	m.table.Store(fresh)
+}
+
+func (m *Cuckoo[K, V]) stripe(b uint64) *cuckooStripe {
+	return &m.stripes[b&(cuckooStripes-1)]
+}
+
+// lock locks the stripes of both the buckets, in index order to avoid
+// deadlocks.
+func (m *Cuckoo[K, V]) lock(b1, b2 uint64) {
+	i, j := b1&(cuckooStripes-1), b2&(cuckooStripes-1)
+	if i > j {
+		i, j = j, i
+	}
+
+	m.stripes[i].mu.Lock()
+	if i != j {
+		m.stripes[j].mu.Lock()
+	}
+}
+
+func (m *Cuckoo[K, V]) unlock(b1, b2 uint64) {
+	i, j := b1&(cuckooStripes-1), b2&(cuckooStripes-1)
+
+	m.stripes[i].mu.Unlock()
+	if i != j {
+		m.stripes[j].mu.Unlock()
+	}
+}
+
+// bump increments the versions of the stripes of both the buckets,
+// which must be locked.
+func (m *Cuckoo[K, V]) bump(b1, b2 uint64) {
+	i, j := b1&(cuckooStripes-1), b2&(cuckooStripes-1)
+
+	m.stripes[i].version.Add(1)
+	if i != j {
+		m.stripes[j].version.Add(1)
+	}
+}
+
+func (m *Cuckoo[K, V]) lockAll() {
+	for i := range m.stripes {
+		m.stripes[i].mu.Lock()
+	}
+}
+
+func (m *Cuckoo[K, V]) unlockAll() {
+	for i := range m.stripes {
+		m.stripes[i].mu.Unlock()
+	}
+}
@@ -0,0 +1,163 @@
+package hashmap
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+
+	"github.com/cespare/xxhash"
+)
+
+// Hasher maps a key to a 64 bit hash. Hashers must be deterministic for the
+// lifetime of a map and safe for concurrent use.
+type Hasher[K comparable] func(key K) uint64
+
+// DefaultHasher returns the hasher used by the maps when none is provided.
+//
+// It is backed by hash/maphash which outperforms xxhash for the small
+// inputs typical of map keys (see BenchmarkHashing in internal/bench).
+func DefaultHasher[K comparable]() Hasher[K] {
+	return MapHasher[K]()
+}
+
+// MapHasher returns a Hasher backed by hash/maphash with a random seed.
+func MapHasher[K comparable]() Hasher[K] {
+	seed := maphash.MakeSeed()
+
+	return func(key K) uint64 {
+		if s, ok := any(key).(string); ok {
+			return maphash.String(seed, s)
+		}
+
+		var buf [16]byte
+		if b, ok := appendKey(buf[:0], key); ok {
+			return maphash.Bytes(seed, b)
+		}
+
+		var big [64]byte
+		return maphash.Bytes(seed, appendValue(big[:0], reflect.ValueOf(key)))
+	}
+}
+
+// XXHasher returns a Hasher backed by xxhash.
+func XXHasher[K comparable]() Hasher[K] {
+	return func(key K) uint64 {
+		if s, ok := any(key).(string); ok {
+			return xxhash.Sum64String(s)
+		}
+
+		var buf [16]byte
+		if b, ok := appendKey(buf[:0], key); ok {
+			return xxhash.Sum64(b)
+		}
+
+		var big [64]byte
+		return xxhash.Sum64(appendValue(big[:0], reflect.ValueOf(key)))
+	}
+}
+
+// appendKey appends the byte representation of key to buf if it is of a
+// fixed size kind. Other keys go through appendValue, which is kept out of
+// here so that the common case does not pay for reflection.
+func appendKey(buf []byte, key any) ([]byte, bool) {
+	switch k := key.(type) {
+	case int:
+		return binary.LittleEndian.AppendUint64(buf, uint64(k)), true
+	case int8:
+		return append(buf, byte(k)), true
+	case int16:
+		return binary.LittleEndian.AppendUint16(buf, uint16(k)), true
+	case int32:
+		return binary.LittleEndian.AppendUint32(buf, uint32(k)), true
+	case int64:
+		return binary.LittleEndian.AppendUint64(buf, uint64(k)), true
+	case uint:
+		return binary.LittleEndian.AppendUint64(buf, uint64(k)), true
+	case uint8:
+		return append(buf, k), true
+	case uint16:
+		return binary.LittleEndian.AppendUint16(buf, k), true
+	case uint32:
+		return binary.LittleEndian.AppendUint32(buf, k), true
+	case uint64:
+		return binary.LittleEndian.AppendUint64(buf, k), true
+	case uintptr:
+		return binary.LittleEndian.AppendUint64(buf, uint64(k)), true
+	case float32:
+		if k == 0 {
+			k = 0 // -0 == +0 so both must hash alike
+		}
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(k)), true
+	case float64:
+		if k == 0 {
+			k = 0
+		}
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(k)), true
+	case bool:
+		if k {
+			return append(buf, 1), true
+		}
+		return append(buf, 0), true
+	default:
+		return buf, false
+	}
+}
+
+// appendValue appends a byte representation of v to buf such that values
+// equal under == append the same bytes. Pointers, channels and unsafe
+// pointers are equal when they point to the same place so their address is
+// appended, structs and arrays append their fields and elements one after
+// the other.
+func appendValue(buf []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.LittleEndian.AppendUint64(buf, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.LittleEndian.AppendUint64(buf, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(buf, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return appendFloat(appendFloat(buf, real(c)), imag(c))
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+	case reflect.String:
+		// the length keeps {"ab", "c"} and {"a", "bc"} apart
+		s := v.String()
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(s)))
+		return append(buf, s...)
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return binary.LittleEndian.AppendUint64(buf, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return append(buf, 0)
+		}
+		return appendValue(append(buf, 1), v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			buf = appendValue(buf, v.Index(i))
+		}
+		return buf
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			buf = appendValue(buf, v.Field(i))
+		}
+		return buf
+	default:
+		// only a nil interface key has no kind, other kinds are not
+		// comparable and cannot be keys
+		return buf
+	}
+}
+
+// appendFloat appends f such that -0 and +0 append the same bytes.
+func appendFloat(buf []byte, f float64) []byte {
+	if f == 0 {
+		f = 0
+	}
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+}
@@ -0,0 +1,76 @@
+// Package hashmap provides concurrent hash maps.
+//
+// All the maps in this package implement Map which mirrors the API of
+// sync.Map so that they can be swapped for one another.
+package hashmap
+
+// Map is a concurrent map from keys of type K to values of type V.
+type Map[K comparable, V any] interface {
+	// Load returns the value stored in the map for a key and reports
+	// whether it was present.
+	Load(key K) (value V, ok bool)
+
+	// Store sets the value for a key.
+	Store(key K, value V)
+
+	// LoadOrStore returns the existing value for the key if present.
+	// Otherwise, it stores and returns the given value. The loaded result
+	// is true if the value was loaded, false if stored.
+	LoadOrStore(key K, value V) (actual V, loaded bool)
+
+	// Delete deletes the value for a key.
+	Delete(key K)
+
+	// Range calls f sequentially for each key and value present in the
+	// map. If f returns false, Range stops the iteration.
+	//
+	// Range does not necessarily correspond to any consistent snapshot of
+	// the map's contents: no key will be visited more than once, but if
+	// the value for any key is stored or deleted concurrently (including
+	// by f), Range may reflect any mapping for that key from any point
+	// during the Range call.
+	Range(f func(key K, value V) bool)
+}
+
+// Option configures a map.
+type Option[K comparable] func(*config[K])
+
+type config[K comparable] struct {
+	hasher Hasher[K]
+	alt    Hasher[K]
+}
+
+func newConfig[K comparable](opts []Option[K]) config[K] {
+	cfg := config[K]{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.hasher == nil {
+		cfg.hasher = DefaultHasher[K]()
+	}
+
+	// a seed of its own keeps it independent of the first hasher, even
+	// when that is a MapHasher or an XXHasher
+	if cfg.alt == nil {
+		cfg.alt = MapHasher[K]()
+	}
+
+	return cfg
+}
+
+// WithHasher sets the hasher used by the map.
+func WithHasher[K comparable](hasher Hasher[K]) Option[K] {
+	return func(c *config[K]) {
+		c.hasher = hasher
+	}
+}
+
+// WithAltHasher sets the second hasher used by maps that need two
+// independent hash functions, like Cuckoo. It defaults to a MapHasher with
+// a seed of its own.
+func WithAltHasher[K comparable](hasher Hasher[K]) Option[K] {
+	return func(c *config[K]) {
+		c.alt = hasher
+	}
+}
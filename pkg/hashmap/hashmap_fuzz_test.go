@@ -0,0 +1,189 @@
+package hashmap_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+)
+
+const (
+	fuzzGoroutines = 4
+
+	// fuzzMaxOps keeps the histories small enough to check quickly
+	fuzzMaxOps = 64
+
+	// fuzzKeys is the number of distinct keys, few enough for the
+	// operations to keep running into each other
+	fuzzKeys = 8
+)
+
+type fuzzOpKind byte
+
+const (
+	fuzzLoad fuzzOpKind = iota
+	fuzzStore
+	fuzzLoadOrStore
+	fuzzDelete
+)
+
+type fuzzOp struct {
+	goroutine int
+	kind      fuzzOpKind
+	key       int
+	value     int
+}
+
+// decodeFuzzProgram decodes the fuzzer's input into the name of the map
+// and a program. The first byte picks the map, then every pair of bytes is
+// an operation: the low two bits of the first byte pick the operation, the
+// next two the goroutine running it, while the low three bits of the
+// second byte are the key and the rest the stored value.
+func decodeFuzzProgram(data []byte) (string, []fuzzOp) {
+	var names []string
+	for name := range implementations() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	name := names[0]
+	if len(data) > 0 {
+		name = names[int(data[0])%len(names)]
+		data = data[1:]
+	}
+
+	var ops []fuzzOp
+	for i := 0; i+1 < len(data) && len(ops) < fuzzMaxOps; i += 2 {
+		ops = append(ops, fuzzOp{
+			goroutine: int(data[i]>>2) % fuzzGoroutines,
+			kind:      fuzzOpKind(data[i] & 3),
+			key:       int(data[i+1]) % fuzzKeys,
+			value:     int(data[i+1]) / fuzzKeys,
+		})
+	}
+
+	return name, ops
+}
+
+func Fuzz_Map(f *testing.F) {
+	f.Add([]byte{0, 1, 8, 0, 0, 3, 0, 0, 0})
+	f.Add([]byte{2, 1, 9, 5, 17, 2, 25, 15, 1, 0, 1, 4, 1})
+	f.Add([]byte{7, 1, 0, 5, 1, 9, 2, 13, 3, 2, 8, 6, 9, 10, 10, 14, 11, 3, 0, 7, 1, 0, 2, 4, 3})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		name, ops := decodeFuzzProgram(data)
+		newMap := implementations()[name]
+
+		t.Run("sequential", func(t *testing.T) {
+			m := newMap()
+
+			model := map[int]int{}
+			for _, op := range ops {
+				switch op.kind {
+				case fuzzLoad:
+					got, ok := m.Load(op.key)
+					want, present := model[op.key]
+					if ok != present || got != want {
+						t.Fatalf("%v: got %v %v, want %v %v", name, got, ok, want, present)
+					}
+				case fuzzStore:
+					m.Store(op.key, op.value)
+					model[op.key] = op.value
+				case fuzzLoadOrStore:
+					got, loaded := m.LoadOrStore(op.key, op.value)
+					want, present := model[op.key]
+					if !present {
+						want = op.value
+						model[op.key] = op.value
+					}
+					if loaded != present || got != want {
+						t.Fatalf("%v: got %v %v, want %v %v", name, got, loaded, want, present)
+					}
+				case fuzzDelete:
+					m.Delete(op.key)
+					delete(model, op.key)
+				}
+			}
+
+			got := map[int]int{}
+			m.Range(func(key, value int) bool {
+				got[key] = value
+				return true
+			})
+
+			if len(got) != len(model) {
+				t.Fatalf("%v: got %v keys, want %v", name, len(got), len(model))
+			}
+
+			for key, want := range model {
+				if got[key] != want {
+					t.Fatalf("%v: got %v, want %v", name, got[key], want)
+				}
+			}
+		})
+
+		t.Run("concurrent", func(t *testing.T) {
+			type input = lincheck.MapInput[int, int]
+			type output = lincheck.MapOutput[int]
+
+			m := newMap()
+			r := lincheck.NewRecorder[input, output]()
+
+			programs := make([][]fuzzOp, fuzzGoroutines)
+			for _, op := range ops {
+				programs[op.goroutine] = append(programs[op.goroutine], op)
+			}
+
+			var wg sync.WaitGroup
+			for g, program := range programs {
+				wg.Add(1)
+				go func(g int, program []fuzzOp) {
+					defer wg.Done()
+
+					for _, op := range program {
+						key, value := op.key, op.value
+
+						switch op.kind {
+						case fuzzLoad:
+							r.Record(g, input{Op: lincheck.Load, Key: key}, func() output {
+								value, ok := m.Load(key)
+								return output{Value: value, Ok: ok}
+							})
+						case fuzzStore:
+							r.Record(g, input{Op: lincheck.Store, Key: key, Value: value}, func() output {
+								m.Store(key, value)
+								return output{}
+							})
+						case fuzzLoadOrStore:
+							r.Record(g, input{Op: lincheck.LoadOrStore, Key: key, Value: value}, func() output {
+								actual, loaded := m.LoadOrStore(key, value)
+								return output{Value: actual, Ok: loaded}
+							})
+						case fuzzDelete:
+							r.Record(g, input{Op: lincheck.Delete, Key: key}, func() output {
+								m.Delete(key)
+								return output{}
+							})
+						}
+					}
+				}(g, program)
+			}
+			wg.Wait()
+
+			// load every key once quiescent so that lost or resurrected
+			// entries show up in the history
+			for key := 0; key < fuzzKeys; key++ {
+				key := key
+				r.Record(fuzzGoroutines, input{Op: lincheck.Load, Key: key}, func() output {
+					value, ok := m.Load(key)
+					return output{Value: value, Ok: ok}
+				})
+			}
+
+			if !lincheck.Check(lincheck.MapModel[int, int](), r.History()) {
+				t.Errorf("%v: history is not linearizable: %v", name, r.History())
+			}
+		})
+	})
+}
@@ -0,0 +1,118 @@
+//go:build godc_sched
+
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+func Test_SplitOrdered_Schedules(t *testing.T) {
+	type input = lincheck.MapInput[int, int]
+	type output = lincheck.MapOutput[int]
+
+	// the default hasher is seeded randomly, which would make the runs
+	// differ from one another
+	opts := map[string][]Option[int]{
+		"identity hasher": {WithHasher(func(key int) uint64 { return uint64(key) })},
+		// every key ends up in the same bucket and shares its sokey
+		"colliding hasher": {WithHasher(func(key int) uint64 { return 1 })},
+	}
+
+	for name, opts := range opts {
+		t.Run(name, func(t *testing.T) {
+			// a store races a delete of the same key while inserts of a
+			// second key race each other
+			program := func() ([]func(), func() error) {
+				m := NewSplitOrdered[int, int](opts...)
+				m.Store(1, 1)
+
+				r := lincheck.NewRecorder[input, output]()
+				r.Record(0, input{Op: lincheck.Store, Key: 1, Value: 1}, func() output {
+					return output{}
+				})
+
+				store := func(client, key, value int) {
+					r.Record(client, input{Op: lincheck.Store, Key: key, Value: value}, func() output {
+						m.Store(key, value)
+						return output{}
+					})
+				}
+
+				loadOrStore := func(client, key, value int) {
+					r.Record(client, input{Op: lincheck.LoadOrStore, Key: key, Value: value}, func() output {
+						actual, loaded := m.LoadOrStore(key, value)
+						return output{Value: actual, Ok: loaded}
+					})
+				}
+
+				del := func(client, key int) {
+					r.Record(client, input{Op: lincheck.Delete, Key: key}, func() output {
+						m.Delete(key)
+						return output{}
+					})
+				}
+
+				threads := []func(){
+					func() {
+						store(1, 1, 10)
+					},
+					func() {
+						del(2, 1)
+						loadOrStore(2, 2, 20)
+					},
+					func() {
+						loadOrStore(3, 1, 30)
+						store(3, 2, 40)
+					},
+				}
+
+				return threads, func() error {
+					seen := map[int]int{}
+					m.Range(func(key, value int) bool {
+						seen[key]++
+						return true
+					})
+
+					present := 0
+					for key := 1; key <= 2; key++ {
+						key := key
+						out := r.Record(4, input{Op: lincheck.Load, Key: key}, func() output {
+							value, ok := m.Load(key)
+							return output{Value: value, Ok: ok}
+						})
+
+						if out.Ok {
+							present++
+						}
+
+						if want := map[bool]int{true: 1}[out.Ok]; seen[key] != want {
+							return fmt.Errorf("key %v: got %v visits, want %v", key, seen[key], want)
+						}
+					}
+
+					if got := m.Len(); got != present {
+						return fmt.Errorf("got %v, want %v", got, present)
+					}
+
+					if !lincheck.Check(lincheck.MapModel[int, int](), r.History()) {
+						return fmt.Errorf("history is not linearizable: %v", r.History())
+					}
+
+					return nil
+				}
+			}
+
+			if f := sched.Exhaustive(program, 8, 0); f != nil {
+				t.Errorf("%v", sched.Shrink(program, f))
+			}
+
+			if f := sched.Random(program, 1, 500); f != nil {
+				t.Errorf("%v", sched.Shrink(program, f))
+			}
+		})
+	}
+}
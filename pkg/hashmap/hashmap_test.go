@@ -0,0 +1,719 @@
+package hashmap_test
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+	"github.com/tangledbytes/godc/internal/util"
+	"github.com/tangledbytes/godc/pkg/hashmap"
+)
+
+// implementations returns a constructor for every map in the package so
+// that the same tests run against all of them.
+func implementations() map[string]func() hashmap.Map[int, int] {
+	return map[string]func() hashmap.Map[int, int]{
+		"split ordered": func() hashmap.Map[int, int] {
+			return hashmap.NewSplitOrdered[int, int]()
+		},
+		"split ordered - xxhash": func() hashmap.Map[int, int] {
+			return hashmap.NewSplitOrdered[int, int](hashmap.WithHasher(hashmap.XXHasher[int]()))
+		},
+		"split ordered - colliding hasher": func() hashmap.Map[int, int] {
+			return hashmap.NewSplitOrdered[int, int](hashmap.WithHasher(func(key int) uint64 {
+				return uint64(key % 7)
+			}))
+		},
+		"striped": func() hashmap.Map[int, int] {
+			return hashmap.NewStriped[int, int]()
+		},
+		"refinable": func() hashmap.Map[int, int] {
+			return hashmap.NewRefinable[int, int]()
+		},
+		"cuckoo": func() hashmap.Map[int, int] {
+			return hashmap.NewCuckoo[int, int]()
+		},
+		"cuckoo - colliding hashers": func() hashmap.Map[int, int] {
+			return hashmap.NewCuckoo[int, int](
+				hashmap.WithHasher(func(key int) uint64 {
+					return uint64(key % 7)
+				}),
+				hashmap.WithAltHasher(func(key int) uint64 {
+					return uint64(key%7) + 7
+				}),
+			)
+		},
+		"hopscotch": func() hashmap.Map[int, int] {
+			return hashmap.NewHopscotch[int, int]()
+		},
+		"hopscotch - colliding hasher": func() hashmap.Map[int, int] {
+			return hashmap.NewHopscotch[int, int](hashmap.WithHasher(func(key int) uint64 {
+				return uint64(key % 7)
+			}))
+		},
+	}
+}
+
+func Test_Map_Store(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		type test struct {
+			name string
+			data []int
+			want []int
+		}
+
+		tests := []test{
+			{
+				name: "empty",
+				data: []int{},
+				want: []int{},
+			},
+			{
+				name: "single element",
+				data: []int{1},
+				want: []int{1},
+			},
+			{
+				name: "overwrite",
+				data: []int{1, 1, 2, 1},
+				want: []int{1, 2},
+			},
+			{
+				name: "multiple elements",
+				data: util.GenerateRandomIntSeries(1, 1000),
+				want: util.GenerateIntSeries(1, 1000),
+			},
+		}
+
+		for name, newMap := range implementations() {
+			for _, tt := range tests {
+				t.Run(name+" - "+tt.name, func(t *testing.T) {
+					m := newMap()
+
+					for _, data := range tt.data {
+						m.Store(data, data*10)
+					}
+
+					for _, data := range tt.want {
+						if got, ok := m.Load(data); got != data*10 || !ok {
+							t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{data * 10, true})
+						}
+					}
+
+					if got, ok := m.Load(-1); ok {
+						t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{0, false})
+					}
+
+					if got := keys(m); !equal(got, tt.want) {
+						t.Errorf("got %v, want %v", got, tt.want)
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+				data := util.GenerateIntSeries(1, 1000)
+
+				var wg sync.WaitGroup
+				for _, d := range data {
+					wg.Add(1)
+					go func(d int) {
+						m.Store(d, d*10)
+						wg.Done()
+					}(d)
+				}
+				wg.Wait()
+
+				for _, d := range data {
+					if got, ok := m.Load(d); got != d*10 || !ok {
+						t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{d * 10, true})
+					}
+				}
+
+				if got := keys(m); !equal(got, data) {
+					t.Errorf("got %v, want %v", got, data)
+				}
+			})
+		}
+	})
+}
+
+func Test_Map_LoadOrStore(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				if got, loaded := m.LoadOrStore(1, 10); got != 10 || loaded {
+					t.Errorf("got %v, want %v", [2]interface{}{got, loaded}, [2]interface{}{10, false})
+				}
+
+				if got, loaded := m.LoadOrStore(1, 20); got != 10 || !loaded {
+					t.Errorf("got %v, want %v", [2]interface{}{got, loaded}, [2]interface{}{10, true})
+				}
+
+				m.Delete(1)
+				if got, loaded := m.LoadOrStore(1, 30); got != 30 || loaded {
+					t.Errorf("got %v, want %v", [2]interface{}{got, loaded}, [2]interface{}{30, false})
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				var wg sync.WaitGroup
+				var mu sync.Mutex
+				stored := map[int]int{}
+
+				// every goroutine races to store its own value for the
+				// same keys, exactly one of them must win per key
+				for g := 0; g < 8; g++ {
+					wg.Add(1)
+					go func(g int) {
+						defer wg.Done()
+
+						for k := 0; k < 200; k++ {
+							if _, loaded := m.LoadOrStore(k, g); !loaded {
+								mu.Lock()
+								stored[k]++
+								mu.Unlock()
+							}
+						}
+					}(g)
+				}
+				wg.Wait()
+
+				for k := 0; k < 200; k++ {
+					if stored[k] != 1 {
+						t.Errorf("key %v stored %v times, want 1", k, stored[k])
+					}
+				}
+			})
+		}
+	})
+}
+
+func Test_Map_Delete(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		type test struct {
+			name   string
+			data   []int
+			remove []int
+			final  []int
+		}
+
+		tests := []test{
+			{
+				name:   "empty",
+				data:   []int{},
+				remove: []int{1},
+				final:  []int{},
+			},
+			{
+				name:   "single element",
+				data:   []int{1},
+				remove: []int{1, 1},
+				final:  []int{},
+			},
+			{
+				name:   "multiple elements",
+				data:   util.GenerateIntSeries(1, 1000),
+				remove: util.GenerateIntSeries(1, 200),
+				final:  util.GenerateIntSeries(201, 1000),
+			},
+		}
+
+		for name, newMap := range implementations() {
+			for _, tt := range tests {
+				t.Run(name+" - "+tt.name, func(t *testing.T) {
+					m := newMap()
+
+					for _, data := range tt.data {
+						m.Store(data, data)
+					}
+
+					for _, data := range tt.remove {
+						m.Delete(data)
+
+						if got, ok := m.Load(data); ok {
+							t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{0, false})
+						}
+					}
+
+					if got := keys(m); !equal(got, tt.final) {
+						t.Errorf("got %v, want %v", got, tt.final)
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				for _, d := range util.GenerateIntSeries(1, 1000) {
+					m.Store(d, d)
+				}
+
+				var wg sync.WaitGroup
+				for _, d := range util.GenerateIntSeries(1, 500) {
+					wg.Add(2)
+					go func(d int) {
+						m.Delete(d)
+						wg.Done()
+					}(d)
+
+					// concurrent inserts of fresh keys
+					go func(d int) {
+						m.Store(d+1000, d+1000)
+						wg.Done()
+					}(d)
+				}
+				wg.Wait()
+
+				want := util.GenerateIntSeries(501, 1500)
+				if got := keys(m); !equal(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func Test_Map_Range(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				for _, d := range util.GenerateIntSeries(1, 100) {
+					m.Store(d, d*10)
+				}
+
+				m.Range(func(key, value int) bool {
+					if value != key*10 {
+						t.Errorf("got %v, want %v", value, key*10)
+					}
+					return true
+				})
+
+				count := 0
+				m.Range(func(key, value int) bool {
+					count++
+					return count < 10
+				})
+
+				if count != 10 {
+					t.Errorf("got %v, want %v", count, 10)
+				}
+			})
+		}
+	})
+
+	t.Run("growing while ranging", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				stable := util.GenerateIntSeries(1, 1000)
+				for _, d := range stable {
+					m.Store(d, d)
+				}
+
+				// f grows the map enough for it to resize, the stable keys
+				// must still be visited exactly once
+				visits := map[int]int{}
+				m.Range(func(key, _ int) bool {
+					if len(visits) == 0 {
+						for _, d := range util.GenerateIntSeries(10001, 20000) {
+							m.Store(d, d)
+						}
+					}
+
+					visits[key]++
+					return true
+				})
+
+				for key, n := range visits {
+					if n != 1 {
+						t.Errorf("key %v visited %v times, want 1", key, n)
+					}
+				}
+
+				for _, d := range stable {
+					if visits[d] != 1 {
+						t.Errorf("key %v visited %v times, want 1", d, visits[d])
+					}
+				}
+			})
+		}
+	})
+}
+
+func Test_SplitOrdered_Len(t *testing.T) {
+	m := hashmap.NewSplitOrdered[string, int]()
+
+	for i, k := range []string{"a", "b", "c", "b"} {
+		m.Store(k, i)
+	}
+	m.Delete("a")
+	m.Delete("z")
+
+	if got := m.Len(); got != 2 {
+		t.Errorf("got %v, want %v", got, 2)
+	}
+}
+
+func Test_Map_Displacement(t *testing.T) {
+	type sizedMap interface {
+		hashmap.Map[int, int]
+		Len() int
+	}
+
+	// maps which move entries around to make room and grow their table
+	displacing := map[string]func() sizedMap{
+		"cuckoo": func() sizedMap {
+			return hashmap.NewCuckoo[int, int]()
+		},
+		"hopscotch": func() sizedMap {
+			return hashmap.NewHopscotch[int, int]()
+		},
+	}
+
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newMap := range displacing {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				data := util.GenerateRandomIntSeries(1, 100000)
+				for _, d := range data {
+					m.Store(d, d)
+				}
+
+				for _, d := range data {
+					if got, ok := m.Load(d); got != d || !ok {
+						t.Fatalf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{d, true})
+					}
+				}
+
+				if got := m.Len(); got != len(data) {
+					t.Errorf("got %v, want %v", got, len(data))
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, newMap := range displacing {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				// the stable keys are never removed, so readers must always
+				// find them even while writers move them around and grow the
+				// table
+				stable := util.GenerateIntSeries(1, 1000)
+				for _, d := range stable {
+					m.Store(d, d)
+				}
+
+				var wg sync.WaitGroup
+				var done atomic.Bool
+
+				for w := 0; w < 4; w++ {
+					wg.Add(1)
+					go func(w int) {
+						defer wg.Done()
+
+						for i := 0; i < 20000; i++ {
+							key := 1000000*(w+1) + i
+							m.Store(key, key)
+							if i%2 == 0 {
+								m.Delete(key)
+							}
+						}
+					}(w)
+				}
+
+				var missed atomic.Int64
+				var readers sync.WaitGroup
+				for r := 0; r < 4; r++ {
+					readers.Add(1)
+					go func() {
+						defer readers.Done()
+
+						for !done.Load() {
+							for _, d := range stable {
+								if got, ok := m.Load(d); got != d || !ok {
+									missed.Add(1)
+								}
+							}
+						}
+					}()
+				}
+
+				wg.Wait()
+				done.Store(true)
+				readers.Wait()
+
+				if missed.Load() != 0 {
+					t.Errorf("readers missed %v stable keys", missed.Load())
+				}
+
+				if got, want := m.Len(), len(stable)+4*10000; got != want {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func Test_Cuckoo_Hashers(t *testing.T) {
+	type test struct {
+		name string
+		opts []hashmap.Option[int]
+	}
+
+	tests := []test{
+		{
+			name: "xxhash as the first hasher",
+			opts: []hashmap.Option[int]{
+				hashmap.WithHasher(hashmap.XXHasher[int]()),
+			},
+		},
+		{
+			name: "identical hashers",
+			opts: []hashmap.Option[int]{
+				hashmap.WithHasher(hashmap.XXHasher[int]()),
+				hashmap.WithAltHasher(hashmap.XXHasher[int]()),
+			},
+		},
+		{
+			// every key lives in bucket 0 or 1 whatever the size, which
+			// holds no more than 8 of them
+			name: "degenerate hashers",
+			opts: []hashmap.Option[int]{
+				hashmap.WithHasher(func(int) uint64 { return 0 }),
+				hashmap.WithAltHasher(func(int) uint64 { return 1 }),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := hashmap.NewCuckoo[int, int](tt.opts...)
+
+			data := util.GenerateIntSeries(1, 2000)
+			for _, d := range data {
+				m.Store(d, d)
+			}
+
+			for _, d := range data {
+				if got, ok := m.Load(d); got != d || !ok {
+					t.Fatalf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{d, true})
+				}
+			}
+
+			for _, d := range data[:1000] {
+				m.Delete(d)
+			}
+
+			want := data[1000:]
+			if got := keys(m); !equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			if got := m.Len(); got != len(want) {
+				t.Errorf("got %v, want %v", got, len(want))
+			}
+		})
+	}
+}
+
+func Test_Hasher(t *testing.T) {
+	type key struct {
+		a int
+		b string
+	}
+
+	hashers := map[string]hashmap.Hasher[key]{
+		"maphash": hashmap.MapHasher[key](),
+		"xxhash":  hashmap.XXHasher[key](),
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			if hasher(key{1, "a"}) != hasher(key{1, "a"}) {
+				t.Errorf("equal keys hashed differently")
+			}
+
+			if hasher(key{1, "a"}) == hasher(key{2, "a"}) {
+				t.Errorf("different keys hashed alike")
+			}
+		})
+	}
+}
+
+func Test_Hasher_PointerKeys(t *testing.T) {
+	type point struct {
+		X int
+	}
+
+	hashers := map[string]hashmap.Hasher[*point]{
+		"maphash": hashmap.MapHasher[*point](),
+		"xxhash":  hashmap.XXHasher[*point](),
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			k := &point{1}
+			h := hasher(k)
+			k.X = 2
+			if got := hasher(k); got != h {
+				t.Errorf("got %v, want %v", got, h)
+			}
+
+			m := hashmap.NewSplitOrdered[*point, int](hashmap.WithHasher(hasher))
+			m.Store(k, 1)
+			k.X = 3
+			if got, ok := m.Load(k); !ok || got != 1 {
+				t.Errorf("got %v %v, want %v %v", got, ok, 1, true)
+			}
+
+			if _, ok := m.Load(&point{3}); ok {
+				t.Errorf("got %v, want %v", ok, false)
+			}
+		})
+	}
+}
+
+func Test_Hasher_NegativeZero(t *testing.T) {
+	type point struct {
+		X float64
+		Y [2]float32
+	}
+
+	hashers := map[string]hashmap.Hasher[point]{
+		"maphash": hashmap.MapHasher[point](),
+		"xxhash":  hashmap.XXHasher[point](),
+	}
+
+	negative := point{X: math.Copysign(0, -1), Y: [2]float32{float32(math.Copysign(0, -1)), 1}}
+	positive := point{X: 0, Y: [2]float32{0, 1}}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			if negative != positive {
+				t.Fatalf("got %v != %v, want them equal", negative, positive)
+			}
+
+			if got, want := hasher(negative), hasher(positive); got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			m := hashmap.NewSplitOrdered[point, int](hashmap.WithHasher(hasher))
+			m.Store(negative, 1)
+			if got, ok := m.Load(positive); !ok || got != 1 {
+				t.Errorf("got %v %v, want %v %v", got, ok, 1, true)
+			}
+		})
+	}
+}
+
+func keys(m hashmap.Map[int, int]) []int {
+	got := []int{}
+	m.Range(func(key, _ int) bool {
+		got = append(got, key)
+		return true
+	})
+
+	return got
+}
+
+// equal reports whether a and b contain the same elements with the same
+// multiplicity.
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]int(nil), a...)
+	b = append([]int(nil), b...)
+	sort.Ints(a)
+	sort.Ints(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func Test_Map_Linearizable(t *testing.T) {
+	const goroutines, ops, keys = 4, 250, 8
+
+	type input = lincheck.MapInput[int, int]
+	type output = lincheck.MapOutput[int]
+
+	for name, newMap := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			r := lincheck.NewRecorder[input, output]()
+
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+
+					rng := util.NewRand(int64(g))
+					for i := 0; i < ops; i++ {
+						key, value := rng.Intn(keys), g*ops+i
+
+						switch rng.Intn(4) {
+						case 0:
+							r.Record(g, input{Op: lincheck.Load, Key: key}, func() output {
+								v, ok := m.Load(key)
+								return output{Value: v, Ok: ok}
+							})
+						case 1:
+							r.Record(g, input{Op: lincheck.Store, Key: key, Value: value}, func() output {
+								m.Store(key, value)
+								return output{}
+							})
+						case 2:
+							r.Record(g, input{Op: lincheck.LoadOrStore, Key: key, Value: value}, func() output {
+								v, loaded := m.LoadOrStore(key, value)
+								return output{Value: v, Ok: loaded}
+							})
+						default:
+							r.Record(g, input{Op: lincheck.Delete, Key: key}, func() output {
+								m.Delete(key)
+								return output{}
+							})
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			if !lincheck.Check(lincheck.MapModel[int, int](), r.History()) {
+				t.Errorf("history is not linearizable")
+			}
+		})
+	}
+}
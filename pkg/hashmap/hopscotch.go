@@ -0,0 +1,447 @@
+package hashmap
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// hopRange is the size of a neighbourhood, an entry is always within
+	// hopRange buckets of its home bucket.
+	hopRange = 32
+
+	// hopMaxProbe is how far past its home bucket an insert looks for a
+	// free bucket before giving up on the table.
+	hopMaxProbe = 256
+
+	// hopSegment is the number of consecutive buckets guarded by a lock.
+	hopSegment = 64
+
+	hopInitial = 256
+)
+
+type hopEntry[K comparable, V any] struct {
+	key   K
+	value V
+	hash  uint64
+}
+
+func (e *hopEntry[K, V]) entryKey() K {
+	return e.key
+}
+
+type hopBucket[K comparable, V any] struct {
+	// hop has bit i set if bucket+i holds an entry whose home is this
+	// bucket
+	hop   atomic.Uint32
+	entry atomic.Pointer[hopEntry[K, V]]
+}
+
+// hopLock guards a segment of buckets. The timestamp is odd while an
+// entry whose home is in the segment is being moved, readers use it to
+// detect that they might have missed the entry.
+type hopLock struct {
+	mu sync.Mutex
+	ts atomic.Uint64
+	_  [48]byte
+}
+
+type hopTable[K comparable, V any] struct {
+	// buckets has hopMaxProbe extra buckets past the last home bucket so
+	// that neighbourhoods never wrap around, which keeps the locks
+	// always acquired in ascending order
+	buckets []hopBucket[K, V]
+	locks   []hopLock
+	mask    uint64
+
+	overflow overflow[K, *hopEntry[K, V]]
+}
+
+func newHopTable[K comparable, V any](size int) *hopTable[K, V] {
+	n := size + hopMaxProbe
+
+	return &hopTable[K, V]{
+		buckets: make([]hopBucket[K, V], n),
+		locks:   make([]hopLock, (n+hopSegment-1)/hopSegment),
+		mask:    uint64(size - 1),
+	}
+}
+
+func (t *hopTable[K, V]) home(hash uint64) int {
+	return int(hash & t.mask)
+}
+
+func (t *hopTable[K, V]) segment(b int) *hopLock {
+	return &t.locks[b/hopSegment]
+}
+
+// find returns the index of the bucket holding key along with its entry,
+// or -1.
+func (t *hopTable[K, V]) find(h int, key K) (int, *hopEntry[K, V]) {
+	hop := t.buckets[h].hop.Load()
+	for hop != 0 {
+		i := h + bits.TrailingZeros32(hop)
+		if e := t.buckets[i].entry.Load(); e != nil && e.key == key {
+			return i, e
+		}
+
+		hop &= hop - 1
+	}
+
+	return -1, nil
+}
+
+// free returns the first free bucket in [from, to), or -1.
+func (t *hopTable[K, V]) free(from, to int) int {
+	for i := from; i < to; i++ {
+		if t.buckets[i].entry.Load() == nil {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// closer moves the free bucket f closer to the start of the table by
+// swapping it with an entry which stays within its neighbourhood, and
+// returns the new free bucket or -1 if none could be moved. The caller
+// must hold the locks of every bucket in [f-hopRange+1, f].
+func (t *hopTable[K, V]) closer(f int) int {
+	for c := f - (hopRange - 1); c < f; c++ {
+		hop := t.buckets[c].hop.Load()
+		if hop == 0 {
+			continue
+		}
+
+		i := c + bits.TrailingZeros32(hop)
+		if i >= f {
+			continue
+		}
+
+		l := t.segment(c)
+		l.ts.Add(1)
+
+		t.buckets[f].entry.Store(t.buckets[i].entry.Load())
+		t.buckets[c].hop.Store(hop | 1<<(f-c))
+		t.buckets[i].entry.Store(nil)
+		t.buckets[c].hop.Store(hop&^(1<<(i-c)) | 1<<(f-c))
+
+		l.ts.Add(1)
+		return i
+	}
+
+	return -1
+}
+
+// place stores e in the free bucket f of the neighbourhood of h.
+func (t *hopTable[K, V]) place(h, f int, e *hopEntry[K, V]) {
+	t.buckets[f].entry.Store(e)
+	t.buckets[h].hop.Store(t.buckets[h].hop.Load() | 1<<(f-h))
+}
+
+// add inserts e into a table which is not yet published and reports
+// whether it fit.
+func (t *hopTable[K, V]) add(e *hopEntry[K, V]) bool {
+	h := t.home(e.hash)
+
+	f := t.free(h, h+hopMaxProbe)
+	for f >= 0 && f-h >= hopRange {
+		f = t.closer(f)
+	}
+
+	if f < 0 {
+		return false
+	}
+
+	t.place(h, f, e)
+	return true
+}
+
+// Hopscotch is a concurrent open addressing hash map based on hopscotch
+// hashing by Herlihy, Shavit and Tzafrir.
+//
+// Every entry lives within a small neighbourhood of its home bucket which
+// is described by a bitmap stored in the home bucket, so a lookup touches
+// only a couple of cache lines. Inserts probe linearly for a free bucket
+// and then hop it back into the neighbourhood by moving other entries.
+//
+// Writers lock the segments of the buckets they touch while lookups take
+// no locks and use the segment timestamps to detect concurrent moves.
+//
+// An insert which finds no free bucket doubles the table if it is at
+// least half full. Otherwise too many keys share the same buckets, which
+// a bigger table would not fix, and the entry goes to a small locked
+// overflow list instead.
+type Hopscotch[K comparable, V any] struct {
+	hasher Hasher[K]
+	table  atomic.Pointer[hopTable[K, V]]
+	count  atomic.Int64
+}
+
+func NewHopscotch[K comparable, V any](opts ...Option[K]) *Hopscotch[K, V] {
+	cfg := newConfig(opts)
+
+	m := &Hopscotch[K, V]{
+		hasher: cfg.hasher,
+	}
+	m.table.Store(newHopTable[K, V](hopInitial))
+
+	return m
+}
+
+// Load returns the value stored in the map for a key and reports
+// whether it was present.
+func (m *Hopscotch[K, V]) Load(key K) (V, bool) {
+	var def V
+
+	hash := m.hasher(key)
+	for {
+		t := m.table.Load()
+		h := t.home(hash)
+		l := t.segment(h)
+
+		ts := l.ts.Load()
+		if ts&1 == 1 {
+			// an entry is being moved
+			runtime.Gosched()
+			continue
+		}
+
+		_, e := t.find(h, key)
+		if e == nil {
+			e, _ = t.overflow.load(key)
+		}
+
+		// retry if an entry was moved or the table got replaced while
+		// we were looking
+		if l.ts.Load() != ts || m.table.Load() != t {
+			continue
+		}
+
+		if e == nil {
+			return def, false
+		}
+
+		return e.value, true
+	}
+}
+
+// Store sets the value for a key.
+func (m *Hopscotch[K, V]) Store(key K, value V) {
+	m.put(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (m *Hopscotch[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return m.put(key, value, false)
+}
+
+// Delete deletes the value for a key.
+func (m *Hopscotch[K, V]) Delete(key K) {
+	hash := m.hasher(key)
+	for {
+		t := m.table.Load()
+		h := t.home(hash)
+
+		lo, hi := m.lock(t, h, h+hopRange-1)
+		if m.table.Load() != t {
+			m.unlock(t, lo, hi)
+			continue
+		}
+
+		if i, _ := t.find(h, key); i >= 0 {
+			t.buckets[i].entry.Store(nil)
+			t.buckets[h].hop.Store(t.buckets[h].hop.Load() &^ (1 << (i - h)))
+			m.count.Add(-1)
+		} else {
+			t.overflow.mu.Lock()
+			if i := t.overflow.find(key); i >= 0 {
+				t.overflow.remove(i)
+				m.count.Add(-1)
+			}
+			t.overflow.mu.Unlock()
+		}
+
+		m.unlock(t, lo, hi)
+		return
+	}
+}
+
+// Range calls f sequentially for each key and value present in the
+// map. If f returns false, Range stops the iteration.
+//
+// Range briefly locks the whole map to copy its entries so the iteration
+// is over a consistent snapshot, f is called without holding any lock.
+func (m *Hopscotch[K, V]) Range(f func(key K, value V) bool) {
+	t := m.lockAll()
+
+	entries := make([]*hopEntry[K, V], 0, m.count.Load())
+	for i := range t.buckets {
+		if e := t.buckets[i].entry.Load(); e != nil {
+			entries = append(entries, e)
+		}
+	}
+	t.overflow.mu.Lock()
+	entries = append(entries, t.overflow.entries...)
+	t.overflow.mu.Unlock()
+	m.unlock(t, 0, len(t.locks)-1)
+
+	for _, e := range entries {
+		if !f(e.key, e.value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of items in the map.
+func (m *Hopscotch[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+func (m *Hopscotch[K, V]) put(key K, value V, overwrite bool) (V, bool) {
+	hash := m.hasher(key)
+	e := &hopEntry[K, V]{key: key, value: value, hash: hash}
+
+	for {
+		t := m.table.Load()
+		h := t.home(hash)
+
+		lo, hi := m.lock(t, h, h+hopRange-1)
+		if m.table.Load() != t {
+			m.unlock(t, lo, hi)
+			continue
+		}
+
+		if i, curr := t.find(h, key); i >= 0 {
+			actual := curr.value
+			if overwrite {
+				t.buckets[i].entry.Store(e)
+			}
+
+			m.unlock(t, lo, hi)
+			return actual, true
+		}
+
+		t.overflow.mu.Lock()
+		if i := t.overflow.find(key); i >= 0 {
+			actual := t.overflow.entries[i].value
+			if overwrite {
+				t.overflow.entries[i] = e
+			}
+
+			t.overflow.mu.Unlock()
+			m.unlock(t, lo, hi)
+			return actual, true
+		}
+		t.overflow.mu.Unlock()
+
+		// probe for a free bucket, taking the locks of the segments we
+		// walk into
+		f := -1
+		for i := h; i < h+hopMaxProbe; i++ {
+			if s := i / hopSegment; s > hi {
+				t.locks[s].mu.Lock()
+				hi = s
+			}
+
+			if t.buckets[i].entry.Load() == nil {
+				f = i
+				break
+			}
+		}
+
+		// hop the free bucket back into the neighbourhood
+		for f >= 0 && f-h >= hopRange {
+			f = t.closer(f)
+		}
+
+		if f < 0 && m.count.Load() >= int64(t.mask+1)/2 {
+			m.unlock(t, lo, hi)
+			m.resize(t)
+			continue
+		}
+
+		if f < 0 {
+			t.overflow.mu.Lock()
+			t.overflow.add(e)
+			t.overflow.mu.Unlock()
+			m.count.Add(1)
+
+			m.unlock(t, lo, hi)
+			return value, false
+		}
+
+		t.place(h, f, e)
+		m.count.Add(1)
+
+		m.unlock(t, lo, hi)
+		return value, false
+	}
+}
+
+// resize doubles the table, the entries which do not fit in it go to its
+// overflow list.
+func (m *Hopscotch[K, V]) resize(t *hopTable[K, V]) {
+	if curr := m.lockAll(); curr != t {
+		// someone beat us to it
+		m.unlock(curr, 0, len(curr.locks)-1)
+		return
+	}
+	defer m.unlock(t, 0, len(t.locks)-1)
+
+	fresh := newHopTable[K, V](2 * int(t.mask+1))
+	move := func(e *hopEntry[K, V]) {
+		if !fresh.add(e) {
+			fresh.overflow.add(e)
+		}
+	}
+
+	for i := range t.buckets {
+		if e := t.buckets[i].entry.Load(); e != nil {
+			move(e)
+		}
+	}
+
+	// every writer of t is locked out, the list cannot change
+	for _, e := range t.overflow.entries {
+		move(e)
+	}
+
+	m.table.Store(fresh)
+}
+
+// lock locks the segments covering buckets [from, to] of t and returns
+// the range of segments it locked.
+func (m *Hopscotch[K, V]) lock(t *hopTable[K, V], from, to int) (lo, hi int) {
+	lo, hi = from/hopSegment, to/hopSegment
+	for s := lo; s <= hi; s++ {
+		t.locks[s].mu.Lock()
+	}
+
+	return lo, hi
+}
+
+func (m *Hopscotch[K, V]) unlock(t *hopTable[K, V], lo, hi int) {
+	for s := lo; s <= hi; s++ {
+		t.locks[s].mu.Unlock()
+	}
+}
+
+// lockAll locks every segment of the current table and returns it. The
+// table cannot be replaced while all of its segments are locked.
+func (m *Hopscotch[K, V]) lockAll() *hopTable[K, V] {
+	for {
+		t := m.table.Load()
+		m.lock(t, 0, len(t.buckets)-1)
+
+		if m.table.Load() == t {
+			return t
+		}
+
+		m.unlock(t, 0, len(t.locks)-1)
+	}
+}
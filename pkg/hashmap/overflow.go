@@ -0,0 +1,73 @@
+package hashmap
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// keyed is an entry of a table.
+type keyed[K comparable] interface {
+	entryKey() K
+}
+
+// overflow holds the entries an open addressing table found no place for,
+// which happens when a poor hasher gives too many keys the same buckets.
+// Doubling the table would not help them, so they are kept aside in a
+// locked slice which is only searched once the table came up empty. It is
+// meant to stay small and is empty with a good hasher.
+//
+// Writers lock the buckets of the key before mu, so the key is either in
+// the table or here but never in both.
+type overflow[K comparable, E keyed[K]] struct {
+	mu      sync.Mutex
+	entries []E
+
+	// n is the length of entries, it lets lookups skip the lock while
+	// there is nothing to look at
+	n atomic.Int64
+}
+
+// load returns the entry of key, locking the list if it is not empty.
+func (o *overflow[K, E]) load(key K) (E, bool) {
+	var def E
+	if o.n.Load() == 0 {
+		return def, false
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if i := o.find(key); i >= 0 {
+		return o.entries[i], true
+	}
+
+	return def, false
+}
+
+// find returns the index of the entry of key or -1, mu must be held.
+func (o *overflow[K, E]) find(key K) int {
+	for i, e := range o.entries {
+		if e.entryKey() == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// add appends e, mu must be held unless the table is not yet published.
+func (o *overflow[K, E]) add(e E) {
+	o.entries = append(o.entries, e)
+	o.n.Add(1)
+}
+
+// remove removes the entry at i, mu must be held.
+func (o *overflow[K, E]) remove(i int) {
+	var def E
+
+	last := len(o.entries) - 1
+	o.entries[i] = o.entries[last]
+	o.entries[last] = def
+	o.entries = o.entries[:last]
+	o.n.Add(-1)
+}
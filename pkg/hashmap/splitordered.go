@@ -0,0 +1,378 @@
+package hashmap
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+	"github.com/tangledbytes/godc/pkg/atomicmarkablereference"
+)
+
+const (
+	// soMaxLoad is the average number of items per bucket after which
+	// the bucket count is doubled.
+	soMaxLoad = 4
+
+	// soMaxBucketBits bounds the number of buckets to 1 << soMaxBucketBits.
+	soMaxBucketBits = 32
+)
+
+type soNode[K comparable, V any] struct {
+	key   K
+	sokey uint64
+
+	// value is nil for sentinels and once the node is logically deleted
+	value atomic.Pointer[V]
+	next  *atomicmarkablereference.AtomicMarkableReference[soNode[K, V]]
+}
+
+func (n *soNode[K, V]) sentinel() bool {
+	return n.sokey&1 == 0
+}
+
+// mark marks the next pointer of n so that it gets unlinked.
+func (n *soNode[K, V]) mark() {
+	succ, marked := n.next.Get()
+	for !marked {
+		sched.Yield("hashmap: split ordered mark loaded successor")
+		n.next.CompareAndSet(succ, succ, false, true)
+		succ, marked = n.next.Get()
+	}
+}
+
+// SplitOrdered is a lock-free hash map built on the split-ordered lists of
+// Shalev and Shavit.
+//
+// All the items live in a single lock-free linked list sorted by the bit
+// reversed hash of their keys. Buckets are lazily initialised sentinel
+// nodes pointing into the list, so doubling the bucket count never moves
+// an item and the table grows incrementally without locking.
+//
+// A key is deleted once the value of its node is swapped to nil, only then
+// is the node marked and unlinked. This keeps a Store racing with a Delete
+// from updating a node which is already on its way out.
+//
+// This is adapted from The Art of Multiprocessor Programming, 13.3.
+type SplitOrdered[K comparable, V any] struct {
+	hasher Hasher[K]
+
+	// segments[0] holds bucket 0 and segments[i] holds the buckets in
+	// [1 << (i-1), 1 << i), so a segment never has to be reallocated.
+	segments [soMaxBucketBits + 1]atomic.Pointer[[]atomic.Pointer[soNode[K, V]]]
+	head     *soNode[K, V]
+	size     atomic.Uint64
+	count    atomic.Int64
+}
+
+func NewSplitOrdered[K comparable, V any](opts ...Option[K]) *SplitOrdered[K, V] {
+	cfg := newConfig(opts)
+
+	head := &soNode[K, V]{
+		next: atomicmarkablereference.New[soNode[K, V]](nil, false),
+	}
+
+	m := &SplitOrdered[K, V]{
+		hasher: cfg.hasher,
+		head:   head,
+	}
+	m.size.Store(2)
+	m.slot(0).Store(head)
+
+	return m
+}
+
+// Load returns the value stored in the map for a key and reports
+// whether it was present.
+func (m *SplitOrdered[K, V]) Load(key K) (V, bool) {
+	var def V
+
+	h := m.hasher(key)
+	_, curr, found := m.find(m.bucket(h), regularKey(h), key)
+	if !found {
+		return def, false
+	}
+
+	v := curr.value.Load()
+	if v == nil {
+		return def, false
+	}
+
+	return *v, true
+}
+
+// Store sets the value for a key.
+func (m *SplitOrdered[K, V]) Store(key K, value V) {
+	h := m.hasher(key)
+	sokey := regularKey(h)
+	start := m.bucket(h)
+
+	node := &soNode[K, V]{
+		key:   key,
+		sokey: sokey,
+		next:  atomicmarkablereference.New[soNode[K, V]](nil, false),
+	}
+	node.value.Store(&value)
+
+	for {
+		pred, curr, found := m.find(start, sokey, key)
+		if found {
+			if old := curr.value.Load(); old != nil {
+				sched.Yield("hashmap: split ordered store loaded value")
+				if curr.value.CompareAndSwap(old, &value) {
+					return
+				}
+				continue
+			}
+
+			// curr is being deleted, help unlink it and insert a fresh
+			// node instead
+			curr.mark()
+			continue
+		}
+
+		node.next.Set(curr, false)
+		sched.Yield("hashmap: split ordered store found position")
+		if pred.next.CompareAndSet(curr, node, false, false) {
+			m.grow(m.count.Add(1))
+			return
+		}
+
+		// pred changed while we were linking
+		// -- retry
+	}
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (m *SplitOrdered[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	h := m.hasher(key)
+	sokey := regularKey(h)
+	start := m.bucket(h)
+
+	node := &soNode[K, V]{
+		key:   key,
+		sokey: sokey,
+		next:  atomicmarkablereference.New[soNode[K, V]](nil, false),
+	}
+	node.value.Store(&value)
+
+	for {
+		pred, curr, found := m.find(start, sokey, key)
+		if found {
+			if v := curr.value.Load(); v != nil {
+				return *v, true
+			}
+
+			// curr is being deleted, help unlink it before retrying
+			curr.mark()
+			continue
+		}
+
+		node.next.Set(curr, false)
+		sched.Yield("hashmap: split ordered load or store found position")
+		if pred.next.CompareAndSet(curr, node, false, false) {
+			m.grow(m.count.Add(1))
+			return value, false
+		}
+	}
+}
+
+// Delete deletes the value for a key.
+func (m *SplitOrdered[K, V]) Delete(key K) {
+	h := m.hasher(key)
+	sokey := regularKey(h)
+	start := m.bucket(h)
+
+	for {
+		pred, curr, found := m.find(start, sokey, key)
+		if !found {
+			return
+		}
+
+		old := curr.value.Load()
+		if old == nil {
+			// someone else deleted it
+			return
+		}
+
+		// logically delete the node by clearing its value
+		sched.Yield("hashmap: split ordered delete loaded value")
+		if !curr.value.CompareAndSwap(old, nil) {
+			continue
+		}
+		m.count.Add(-1)
+		curr.mark()
+		sched.Yield("hashmap: split ordered delete marked node")
+
+		// try to unlink it, if this fails then the next find
+		// passing through will do it for us
+		pred.next.CompareAndSet(curr, curr.next.GetReference(), false, false)
+		return
+	}
+}
+
+// Range calls f sequentially for each key and value present in the
+// map. If f returns false, Range stops the iteration.
+func (m *SplitOrdered[K, V]) Range(f func(key K, value V) bool) {
+	curr := m.head.next.GetReference()
+	for curr != nil {
+		succ, marked := curr.next.Get()
+		if v := curr.value.Load(); !marked && v != nil {
+			if !f(curr.key, *v) {
+				return
+			}
+		}
+
+		curr = succ
+	}
+}
+
+// Len returns the number of items in the map.
+func (m *SplitOrdered[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+// find returns the first unmarked node which is either the node with
+// sokey and key or the node it would be inserted before, along with its
+// predecessor. Marked nodes encountered on the way are unlinked.
+func (m *SplitOrdered[K, V]) find(start *soNode[K, V], sokey uint64, key K) (pred, curr *soNode[K, V], found bool) {
+retry:
+	for {
+		pred = start
+		curr = pred.next.GetReference()
+
+		for curr != nil {
+			succ, marked := curr.next.Get()
+			if marked {
+				sched.Yield("hashmap: split ordered find unlinking node")
+				if !pred.next.CompareAndSet(curr, succ, false, false) {
+					// pred was either deleted or got a new successor
+					continue retry
+				}
+
+				curr = succ
+				continue
+			}
+
+			if curr.sokey > sokey {
+				return pred, curr, false
+			}
+
+			// sentinels have unique sokeys while regular nodes can
+			// share one when their hashes collide
+			if curr.sokey == sokey && (curr.sentinel() || curr.key == key) {
+				return pred, curr, true
+			}
+
+			pred = curr
+			curr = succ
+		}
+
+		return pred, nil, false
+	}
+}
+
+// grow doubles the bucket count if the load factor has been exceeded.
+// The new buckets are initialised lazily on first access.
+func (m *SplitOrdered[K, V]) grow(count int64) {
+	// a delete can decrement the count of a node before its insert got to
+	// increment it, which must not read as a huge unsigned count
+	if count <= 0 {
+		return
+	}
+
+	size := m.size.Load()
+	if uint64(count)/size > soMaxLoad && size < 1<<soMaxBucketBits {
+		sched.Yield("hashmap: split ordered grow loaded size")
+		m.size.CompareAndSwap(size, size*2)
+	}
+}
+
+// bucket returns the sentinel node of the bucket for hash h.
+func (m *SplitOrdered[K, V]) bucket(h uint64) *soNode[K, V] {
+	return m.getBucket(h & (m.size.Load() - 1))
+}
+
+func (m *SplitOrdered[K, V]) getBucket(b uint64) *soNode[K, V] {
+	slot := m.slot(b)
+	if sentinel := slot.Load(); sentinel != nil {
+		return sentinel
+	}
+
+	return m.initBucket(b, slot)
+}
+
+// initBucket inserts the sentinel node for bucket b, starting the search
+// from its parent bucket which is b with its most significant bit unset.
+func (m *SplitOrdered[K, V]) initBucket(b uint64, slot *atomic.Pointer[soNode[K, V]]) *soNode[K, V] {
+	parent := m.getBucket(b &^ (1 << (bits.Len64(b) - 1)))
+
+	sentinel := &soNode[K, V]{
+		sokey: sentinelKey(b),
+		next:  atomicmarkablereference.New[soNode[K, V]](nil, false),
+	}
+
+	var key K
+	for {
+		pred, curr, found := m.find(parent, sentinel.sokey, key)
+		if found {
+			// someone else initialised the bucket
+			sentinel = curr
+			break
+		}
+
+		sentinel.next.Set(curr, false)
+		sched.Yield("hashmap: split ordered init bucket found position")
+		if pred.next.CompareAndSet(curr, sentinel, false, false) {
+			break
+		}
+	}
+
+	slot.CompareAndSwap(nil, sentinel)
+	return sentinel
+}
+
+// slot returns the bucket slot for b, allocating its segment if needed.
+func (m *SplitOrdered[K, V]) slot(b uint64) *atomic.Pointer[soNode[K, V]] {
+	seg := bits.Len64(b)
+
+	segment := m.segments[seg].Load()
+	if segment == nil {
+		fresh := make([]atomic.Pointer[soNode[K, V]], segmentLen(seg))
+		if m.segments[seg].CompareAndSwap(nil, &fresh) {
+			segment = &fresh
+		} else {
+			segment = m.segments[seg].Load()
+		}
+	}
+
+	return &(*segment)[b-segmentStart(seg)]
+}
+
+func segmentLen(seg int) uint64 {
+	if seg == 0 {
+		return 1
+	}
+
+	return 1 << (seg - 1)
+}
+
+func segmentStart(seg int) uint64 {
+	if seg == 0 {
+		return 0
+	}
+
+	return 1 << (seg - 1)
+}
+
+// regularKey returns the split order key of an item, the bit reversed
+// hash with the lowest bit set so that it sorts after its bucket sentinel.
+func regularKey(h uint64) uint64 {
+	return bits.Reverse64(h | 1<<63)
+}
+
+// sentinelKey returns the split order key of the sentinel of bucket b.
+func sentinelKey(b uint64) uint64 {
+	return bits.Reverse64(b)
+}
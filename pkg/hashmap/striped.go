@@ -0,0 +1,426 @@
+package hashmap
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// lockedMaxLoad is the average bucket length after which the lock
+	// based maps double their table.
+	lockedMaxLoad = 4
+
+	// defaultStripes is the initial table size and, for Striped, the
+	// number of locks.
+	defaultStripes = 64
+)
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// chain is a bucket of a lock based map, guarded by the lock the key
+// stripes to.
+type chain[K comparable, V any] []entry[K, V]
+
+func (c chain[K, V]) find(key K) int {
+	for i := range c {
+		if c[i].key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (c *chain[K, V]) remove(i int) {
+	last := len(*c) - 1
+	(*c)[i] = (*c)[last]
+	(*c)[last] = entry[K, V]{}
+	*c = (*c)[:last]
+}
+
+// lockedTable holds the buckets and the logic shared by the lock based
+// maps. Callers must hold the lock guarding the bucket they touch.
+type lockedTable[K comparable, V any] struct {
+	hasher Hasher[K]
+	table  []chain[K, V]
+	count  atomic.Int64
+}
+
+func (t *lockedTable[K, V]) bucket(h uint64) *chain[K, V] {
+	return &t.table[h%uint64(len(t.table))]
+}
+
+func (t *lockedTable[K, V]) load(h uint64, key K) (V, bool) {
+	var def V
+
+	c := t.bucket(h)
+	if i := c.find(key); i >= 0 {
+		return (*c)[i].value, true
+	}
+
+	return def, false
+}
+
+// store returns the value associated with key once done and whether a
+// new entry was added.
+func (t *lockedTable[K, V]) store(h uint64, key K, value V, overwrite bool) (V, bool) {
+	c := t.bucket(h)
+	if i := c.find(key); i >= 0 {
+		if overwrite {
+			(*c)[i].value = value
+		}
+		return (*c)[i].value, false
+	}
+
+	*c = append(*c, entry[K, V]{key: key, value: value})
+	t.count.Add(1)
+
+	return value, true
+}
+
+func (t *lockedTable[K, V]) delete(h uint64, key K) {
+	c := t.bucket(h)
+	if i := c.find(key); i >= 0 {
+		c.remove(i)
+		t.count.Add(-1)
+	}
+}
+
+// overloaded reports whether a table of the given size should grow. The
+// size is passed in as the table may only be read under a lock.
+func (t *lockedTable[K, V]) overloaded(size int) bool {
+	return t.count.Load()/int64(size) > lockedMaxLoad
+}
+
+// rehash moves every entry into a table twice the size.
+func (t *lockedTable[K, V]) rehash() {
+	old := t.table
+	t.table = make([]chain[K, V], 2*len(old))
+
+	for _, c := range old {
+		for _, e := range c {
+			b := t.bucket(t.hasher(e.key))
+			*b = append(*b, e)
+		}
+	}
+}
+
+// collect copies the entries of the buckets guarded by lock i of n.
+func (t *lockedTable[K, V]) collect(i, n int) []entry[K, V] {
+	var entries []entry[K, V]
+	for b := i; b < len(t.table); b += n {
+		entries = append(entries, t.table[b]...)
+	}
+
+	return entries
+}
+
+// Striped is a hash map guarded by a fixed array of locks where lock i
+// guards every bucket b with b % len(locks) == i. The table grows while
+// the number of locks stays the same.
+//
+// This is adapted from The Art of Multiprocessor Programming, 13.2.2.
+type Striped[K comparable, V any] struct {
+	lockedTable[K, V]
+	locks []sync.Mutex
+}
+
+func NewStriped[K comparable, V any](opts ...Option[K]) *Striped[K, V] {
+	cfg := newConfig(opts)
+
+	m := &Striped[K, V]{
+		locks: make([]sync.Mutex, defaultStripes),
+	}
+	m.hasher = cfg.hasher
+	m.table = make([]chain[K, V], defaultStripes)
+
+	return m
+}
+
+// Load returns the value stored in the map for a key and reports
+// whether it was present.
+func (m *Striped[K, V]) Load(key K) (V, bool) {
+	h := m.hasher(key)
+
+	lock := m.acquire(h)
+	defer lock.Unlock()
+
+	return m.load(h, key)
+}
+
+// Store sets the value for a key.
+func (m *Striped[K, V]) Store(key K, value V) {
+	m.put(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (m *Striped[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return m.put(key, value, false)
+}
+
+// Delete deletes the value for a key.
+func (m *Striped[K, V]) Delete(key K) {
+	h := m.hasher(key)
+
+	lock := m.acquire(h)
+	defer lock.Unlock()
+
+	m.delete(h, key)
+}
+
+// Range calls f sequentially for each key and value present in the
+// map. If f returns false, Range stops the iteration.
+//
+// The buckets guarded by one lock are copied at a time, f is called
+// without holding any lock.
+func (m *Striped[K, V]) Range(f func(key K, value V) bool) {
+	for i := range m.locks {
+		m.locks[i].Lock()
+		entries := m.collect(i, len(m.locks))
+		m.locks[i].Unlock()
+
+		for _, e := range entries {
+			if !f(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of items in the map.
+func (m *Striped[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+func (m *Striped[K, V]) put(key K, value V, overwrite bool) (V, bool) {
+	h := m.hasher(key)
+
+	lock := m.acquire(h)
+	actual, added := m.store(h, key, value, overwrite)
+	size := len(m.table)
+	lock.Unlock()
+
+	if added && m.overloaded(size) {
+		m.resize(size)
+	}
+
+	return actual, !added
+}
+
+func (m *Striped[K, V]) acquire(h uint64) *sync.Mutex {
+	lock := &m.locks[h%uint64(len(m.locks))]
+	lock.Lock()
+
+	return lock
+}
+
+func (m *Striped[K, V]) resize(size int) {
+	for i := range m.locks {
+		m.locks[i].Lock()
+	}
+	defer func() {
+		for i := range m.locks {
+			m.locks[i].Unlock()
+		}
+	}()
+
+	// someone beat us to it
+	if len(m.table) != size {
+		return
+	}
+
+	m.rehash()
+}
+
+// Refinable is a hash map like Striped except that the lock array is
+// resized along with the table so that each lock always guards the same
+// number of buckets.
+//
+// This is adapted from The Art of Multiprocessor Programming, 13.2.3.
+type Refinable[K comparable, V any] struct {
+	lockedTable[K, V]
+	locks    atomic.Pointer[[]sync.Mutex]
+	resizing atomic.Bool
+}
+
+func NewRefinable[K comparable, V any](opts ...Option[K]) *Refinable[K, V] {
+	cfg := newConfig(opts)
+
+	m := &Refinable[K, V]{}
+	m.hasher = cfg.hasher
+	m.table = make([]chain[K, V], defaultStripes)
+
+	locks := make([]sync.Mutex, defaultStripes)
+	m.locks.Store(&locks)
+
+	return m
+}
+
+// Load returns the value stored in the map for a key and reports
+// whether it was present.
+func (m *Refinable[K, V]) Load(key K) (V, bool) {
+	h := m.hasher(key)
+
+	lock := m.acquire(h)
+	defer lock.Unlock()
+
+	return m.load(h, key)
+}
+
+// Store sets the value for a key.
+func (m *Refinable[K, V]) Store(key K, value V) {
+	m.put(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (m *Refinable[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return m.put(key, value, false)
+}
+
+// Delete deletes the value for a key.
+func (m *Refinable[K, V]) Delete(key K) {
+	h := m.hasher(key)
+
+	lock := m.acquire(h)
+	defer lock.Unlock()
+
+	m.delete(h, key)
+}
+
+// Range calls f sequentially for each key and value present in the
+// map. If f returns false, Range stops the iteration.
+//
+// The map is walked one lock at a time. If a resize happens in between
+// the walk restarts over the new lock array, skipping the keys guarded by
+// the locks already walked so that no key is visited twice.
+func (m *Refinable[K, V]) Range(f func(key K, value V) bool) {
+	// walked are the lock arrays walked before a resize, by their size,
+	// and how many of their locks were done
+	type walk struct {
+		locks, done int
+	}
+	var walked []walk
+
+	visited := func(key K) bool {
+		h := m.hasher(key)
+		for _, w := range walked {
+			if h%uint64(w.locks) < uint64(w.done) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var locks *[]sync.Mutex
+	for i := 0; ; {
+		curr := m.waitResize()
+		if locks != nil && curr != locks {
+			walked = append(walked, walk{locks: len(*locks), done: i})
+			i = 0
+		}
+		locks = curr
+
+		if i >= len(*locks) {
+			return
+		}
+
+		lock := &(*locks)[i]
+		lock.Lock()
+		if m.resizing.Load() || m.locks.Load() != locks {
+			lock.Unlock()
+			continue
+		}
+		entries := m.collect(i, len(*locks))
+		lock.Unlock()
+
+		for _, e := range entries {
+			if len(walked) > 0 && visited(e.key) {
+				continue
+			}
+
+			if !f(e.key, e.value) {
+				return
+			}
+		}
+		i++
+	}
+}
+
+// Len returns the number of items in the map.
+func (m *Refinable[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+func (m *Refinable[K, V]) put(key K, value V, overwrite bool) (V, bool) {
+	h := m.hasher(key)
+
+	lock := m.acquire(h)
+	actual, added := m.store(h, key, value, overwrite)
+	size := len(m.table)
+	lock.Unlock()
+
+	if added && m.overloaded(size) {
+		m.resize(size)
+	}
+
+	return actual, !added
+}
+
+// acquire locks the lock guarding h. If a resize is in progress it
+// waits for it and retries on the new lock array.
+func (m *Refinable[K, V]) acquire(h uint64) *sync.Mutex {
+	for {
+		locks := m.waitResize()
+
+		lock := &(*locks)[h%uint64(len(*locks))]
+		lock.Lock()
+
+		if !m.resizing.Load() && m.locks.Load() == locks {
+			return lock
+		}
+
+		lock.Unlock()
+	}
+}
+
+func (m *Refinable[K, V]) waitResize() *[]sync.Mutex {
+	for m.resizing.Load() {
+		runtime.Gosched()
+	}
+
+	return m.locks.Load()
+}
+
+func (m *Refinable[K, V]) resize(size int) {
+	if !m.resizing.CompareAndSwap(false, true) {
+		return
+	}
+	defer m.resizing.Store(false)
+
+	// quiesce - wait for the current holders to release their locks,
+	// everyone else sees resizing and backs off
+	locks := m.locks.Load()
+	for i := range *locks {
+		(*locks)[i].Lock()
+		(*locks)[i].Unlock()
+	}
+
+	// someone beat us to it
+	if len(m.table) != size {
+		return
+	}
+
+	m.rehash()
+
+	fresh := make([]sync.Mutex, len(m.table))
+	m.locks.Store(&fresh)
+}
@@ -0,0 +1,149 @@
+package locks
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// cohortMaxPasses is how many times in a row a cohort lock may be handed
+// to a waiter of the same cluster before it is released globally, which
+// keeps the other clusters from starving.
+const cohortMaxPasses = 64
+
+const (
+	cohortWaiting int32 = iota
+
+	// cohortLocal means the local lock was handed over but the global lock
+	// still has to be acquired
+	cohortLocal
+
+	// cohortGlobal means both the local and the global lock were handed
+	// over
+	cohortGlobal
+)
+
+type cohortNode struct {
+	state atomic.Int32
+	next  atomic.Pointer[cohortNode]
+}
+
+// cohort is the MCS queue of a single cluster.
+type cohort struct {
+	tail atomic.Pointer[cohortNode]
+
+	// passes counts the local hand overs since the global lock was taken,
+	// only touched while holding the local lock
+	passes int
+
+	_ [48]byte
+}
+
+// Cohort is a hierarchical lock for machines where handing a lock between
+// CPUs of the same cluster, such as a NUMA node, is much cheaper than
+// handing it across clusters. Every cluster queues its waiters in an MCS
+// lock of its own and only the holder of a local lock competes for a
+// global backoff lock. On release the lock is passed to the next waiter
+// of the same cluster, if any, without releasing the global lock.
+//
+// Go does not let goroutines pin themselves to a CPU, so the cluster of a
+// goroutine is looked up on every Lock. It only affects performance, the
+// lock stays correct whichever cluster a goroutine ends up in.
+//
+// This is adapted from Dice, Marathe and Shavit, Lock Cohorting: A General
+// Technique for Designing NUMA Locks, as the C-BO-MCS lock.
+type Cohort struct {
+	global  Backoff
+	cohorts []cohort
+	pool    sync.Pool
+	cluster func() int
+
+	// node and cohort of the current holder, only touched while holding
+	// the lock
+	node   *cohortNode
+	cohort *cohort
+}
+
+// NewCohort returns a cohort lock for the given number of clusters. The
+// cluster function returns the cluster of the calling goroutine, results
+// outside of [0, clusters) are wrapped around. A nil function selects
+// CurrentNode.
+func NewCohort(clusters int, cluster func() int) *Cohort {
+	if clusters < 1 {
+		panic("locks: cohort lock needs at least one cluster")
+	}
+
+	if cluster == nil {
+		cluster = CurrentNode
+	}
+
+	return &Cohort{
+		cohorts: make([]cohort, clusters),
+		cluster: cluster,
+	}
+}
+
+func (l *Cohort) Lock() {
+	c := &l.cohorts[uint(l.cluster())%uint(len(l.cohorts))]
+
+	node, _ := l.pool.Get().(*cohortNode)
+	if node == nil {
+		node = &cohortNode{}
+	}
+	node.next.Store(nil)
+	node.state.Store(cohortWaiting)
+
+	state := cohortLocal
+	if pred := c.tail.Swap(node); pred != nil {
+		pred.next.Store(node)
+
+		var s spin.Spinner
+		for state = node.state.Load(); state == cohortWaiting; state = node.state.Load() {
+			s.Spin()
+		}
+	}
+
+	if state != cohortGlobal {
+		l.global.Lock()
+		c.passes = 0
+	}
+
+	l.node = node
+	l.cohort = c
+}
+
+func (l *Cohort) Unlock() {
+	node, c := l.node, l.cohort
+
+	// someone of the same cluster is waiting, keep the global lock for them
+	if c.tail.Load() != node && c.passes < cohortMaxPasses {
+		c.passes++
+		l.handOver(node, cohortGlobal)
+		return
+	}
+
+	l.global.Unlock()
+
+	if node.next.Load() == nil && c.tail.CompareAndSwap(node, nil) {
+		l.pool.Put(node)
+		return
+	}
+
+	l.handOver(node, cohortLocal)
+}
+
+// handOver passes the local lock to the successor of node, which is
+// either linked already or about to be.
+func (l *Cohort) handOver(node *cohortNode, state int32) {
+	next := node.next.Load()
+
+	var s spin.Spinner
+	for next == nil {
+		s.Spin()
+		next = node.next.Load()
+	}
+
+	next.state.Store(state)
+	l.pool.Put(node)
+}
@@ -0,0 +1,26 @@
+// Package locks provides spin locks, queue locks and a reentrant lock.
+//
+// Every lock in this package implements sync.Locker and, like sync.Mutex,
+// may be unlocked by a goroutine other than the one that locked it, except
+// for ReentrantLock. They are adapted from The Art of Multiprocessor
+// Programming, chapters 7 and 8.
+//
+// Go does not let a goroutine pin itself to a CPU, so a spinning
+// goroutine could keep the lock holder from running. To avoid that every
+// wait loop in this package spins for a short while and then yields the
+// processor on every iteration. Like any spin lock they perform poorly
+// when GOMAXPROCS exceeds the number of CPUs actually available.
+package locks
+
+import "sync"
+
+var (
+	_ sync.Locker = (*TAS)(nil)
+	_ sync.Locker = (*TTAS)(nil)
+	_ sync.Locker = (*Backoff)(nil)
+	_ sync.Locker = (*Anderson)(nil)
+	_ sync.Locker = (*CLH)(nil)
+	_ sync.Locker = (*MCS)(nil)
+	_ sync.Locker = (*Cohort)(nil)
+	_ sync.Locker = (*ReentrantLock)(nil)
+)
@@ -0,0 +1,43 @@
+//go:build godc_sched
+
+package locks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+func Test_Lock_Schedules(t *testing.T) {
+	for name, newLock := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			// the critical section yields between reading and writing the
+			// counter, which loses an update unless the lock excludes the
+			// other goroutines
+			program := func() ([]func(), func() error) {
+				l := newLock()
+				count := 0
+
+				inc := func() {
+					l.Lock()
+					v := count
+					sched.Yield("locks: critical section")
+					count = v + 1
+					l.Unlock()
+				}
+
+				return []func(){inc, inc, inc}, func() error {
+					if count != 3 {
+						return fmt.Errorf("got %v, want %v", count, 3)
+					}
+					return nil
+				}
+			}
+
+			if f := sched.Random(program, 1, 200); f != nil {
+				t.Errorf("%v", sched.Shrink(program, f))
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func implementations() map[string]func() sync.Locker {
+	return map[string]func() sync.Locker{
+		"tas": func() sync.Locker {
+			return &TAS{}
+		},
+		"ttas": func() sync.Locker {
+			return &TTAS{}
+		},
+		"backoff": func() sync.Locker {
+			return NewBackoff(time.Microsecond, 50*time.Microsecond)
+		},
+		"backoff - zero value": func() sync.Locker {
+			return &Backoff{}
+		},
+		"anderson": func() sync.Locker {
+			return NewAnderson(64)
+		},
+		"clh": func() sync.Locker {
+			return &CLH{}
+		},
+		"mcs": func() sync.Locker {
+			return &MCS{}
+		},
+		"to": func() sync.Locker {
+			return &TOLock{}
+		},
+		"composite": func() sync.Locker {
+			return NewComposite(4, 0, 0)
+		},
+		"cohort": func() sync.Locker {
+			// random clusters stand in for the CPUs of a multi socket
+			// machine
+			return NewCohort(4, func() int { return rand.Intn(4) })
+		},
+		"cohort - current node": func() sync.Locker {
+			return NewCohort(2, nil)
+		},
+	}
+}
+
+func timeoutImplementations() map[string]func() TimeoutLocker {
+	return map[string]func() TimeoutLocker{
+		"to": func() TimeoutLocker {
+			return &TOLock{}
+		},
+		"composite": func() TimeoutLocker {
+			return NewComposite(4, time.Microsecond, 10*time.Microsecond)
+		},
+	}
+}
+
+func Test_Lock_MutualExclusion(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newLock := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				l := newLock()
+
+				for i := 0; i < 1000; i++ {
+					l.Lock()
+					l.Unlock()
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		const goroutines, increments = 16, 1000
+
+		for name, newLock := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				l := newLock()
+
+				// the race detector flags any increment that is not
+				// ordered by the lock
+				count := 0
+				inside := 0
+
+				var wg sync.WaitGroup
+				for i := 0; i < goroutines; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						for j := 0; j < increments; j++ {
+							l.Lock()
+							inside++
+							if inside != 1 {
+								t.Errorf("got %v goroutines in the critical section, want 1", inside)
+							}
+							count++
+							inside--
+							l.Unlock()
+						}
+					}()
+				}
+				wg.Wait()
+
+				if count != goroutines*increments {
+					t.Errorf("got %v, want %v", count, goroutines*increments)
+				}
+			})
+		}
+	})
+}
+
+func Test_Lock_Handoff(t *testing.T) {
+	for name, newLock := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			l := newLock()
+			l.Lock()
+
+			// unlocking from another goroutine is allowed
+			done := make(chan struct{})
+			go func() {
+				l.Unlock()
+				close(done)
+			}()
+			<-done
+
+			l.Lock()
+			l.Unlock()
+		})
+	}
+}
+
+func Test_Anderson_Capacity(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("zero capacity did not panic")
+		}
+	}()
+
+	NewAnderson(0)
+}
+
+func Test_Cohort_Cluster(t *testing.T) {
+	t.Run("out of range", func(t *testing.T) {
+		ids := []int{0, 3, 4, -1, math.MaxInt, math.MinInt}
+
+		calls := 0
+		l := NewCohort(4, func() int {
+			id := ids[calls%len(ids)]
+			calls++
+			return id
+		})
+
+		for i := 0; i < 2*len(ids); i++ {
+			l.Lock()
+			l.Unlock()
+		}
+
+		if calls != 2*len(ids) {
+			t.Errorf("got %v, want %v", calls, 2*len(ids))
+		}
+	})
+
+	t.Run("no clusters", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("zero clusters did not panic")
+			}
+		}()
+
+		NewCohort(0, nil)
+	})
+
+	t.Run("current node", func(t *testing.T) {
+		if node := CurrentNode(); node < 0 {
+			t.Errorf("got %v, want a non-negative node", node)
+		}
+	})
+}
+
+func Test_TimeoutLock_TryLock(t *testing.T) {
+	for name, newLock := range timeoutImplementations() {
+		t.Run(name, func(t *testing.T) {
+			l := newLock()
+
+			if !l.TryLock() {
+				t.Fatalf("could not lock a free lock")
+			}
+
+			if l.TryLock() {
+				t.Fatalf("locked a held lock")
+			}
+
+			start := time.Now()
+			if l.TryLockFor(20 * time.Millisecond) {
+				t.Fatalf("locked a held lock")
+			}
+
+			if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+				t.Errorf("gave up after %v, want at least %v", elapsed, 20*time.Millisecond)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			if err := l.LockContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			cancelled, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if err := l.LockContext(cancelled); !errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want %v", err, context.Canceled)
+			}
+
+			// the lock is still usable after waiters gave up
+			l.Unlock()
+			if !l.TryLockFor(time.Second) {
+				t.Fatalf("could not lock a free lock")
+			}
+			l.Unlock()
+
+			if err := l.LockContext(context.Background()); err != nil {
+				t.Errorf("got %v, want %v", err, nil)
+			}
+			l.Unlock()
+		})
+	}
+}
+
+func Test_TimeoutLock_Abandon(t *testing.T) {
+	const goroutines, attempts = 16, 300
+
+	for name, newLock := range timeoutImplementations() {
+		t.Run(name, func(t *testing.T) {
+			l := newLock()
+
+			count := 0
+			inside := 0
+
+			// half the goroutines give up quickly so that the queue is full
+			// of abandoned nodes, the others must still get the lock every
+			// time
+			var wg sync.WaitGroup
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					for j := 0; j < attempts; j++ {
+						if i%2 == 0 {
+							if !l.TryLockFor(time.Duration(j%3) * time.Microsecond) {
+								continue
+							}
+						} else {
+							l.Lock()
+						}
+
+						inside++
+						if inside != 1 {
+							t.Errorf("got %v goroutines in the critical section, want 1", inside)
+						}
+						count++
+						inside--
+						l.Unlock()
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if count < goroutines/2*attempts {
+				t.Errorf("got %v acquisitions, want at least %v", count, goroutines/2*attempts)
+			}
+
+			if !l.TryLock() {
+				t.Errorf("could not lock a free lock")
+			}
+		})
+	}
+}
+
+func Test_ReentrantLock_Reentry(t *testing.T) {
+	var l ReentrantLock
+
+	for i := 1; i <= 3; i++ {
+		l.Lock()
+		if got := l.HoldCount(); got != i {
+			t.Errorf("got %v, want %v", got, i)
+		}
+	}
+
+	// other goroutines neither hold nor get the lock
+	done := make(chan int)
+	go func() {
+		holds := l.HoldCount()
+		l.Lock()
+		l.Unlock()
+		done <- holds
+	}()
+
+	for i := 3; i > 0; i-- {
+		select {
+		case <-done:
+			t.Fatalf("another goroutine got a held lock")
+		case <-time.After(time.Millisecond):
+		}
+
+		l.Unlock()
+	}
+
+	if got := <-done; got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+
+	if got := l.HoldCount(); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+}
+
+func Test_ReentrantLock_MutualExclusion(t *testing.T) {
+	const goroutines, increments = 16, 200
+
+	var l ReentrantLock
+	count := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < increments; j++ {
+				l.Lock()
+				l.Lock()
+				count++
+				l.Unlock()
+				l.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count != goroutines*increments {
+		t.Errorf("got %v, want %v", count, goroutines*increments)
+	}
+}
+
+func Test_ReentrantLock_NotOwner(t *testing.T) {
+	expectPanic := func(t *testing.T, f func()) {
+		t.Helper()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("did not panic")
+			}
+		}()
+
+		f()
+	}
+
+	t.Run("unlock free", func(t *testing.T) {
+		var l ReentrantLock
+		expectPanic(t, l.Unlock)
+	})
+
+	t.Run("unlock by other goroutine", func(t *testing.T) {
+		var l ReentrantLock
+		l.Lock()
+		defer l.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			expectPanic(t, l.Unlock)
+		}()
+		<-done
+	})
+
+	t.Run("condition", func(t *testing.T) {
+		var l ReentrantLock
+		c := l.NewCondition()
+
+		expectPanic(t, c.Await)
+		expectPanic(t, c.Signal)
+		expectPanic(t, c.SignalAll)
+	})
+}
+
+func Test_ReentrantLock_Condition(t *testing.T) {
+	const items = 1000
+
+	var l ReentrantLock
+	notEmpty := l.NewCondition()
+	notFull := l.NewCondition()
+
+	// a bounded buffer, the producer holds the lock twice while waiting
+	// to check that Await releases every hold
+	var buf []int
+	const capacity = 4
+
+	go func() {
+		for i := 0; i < items; i++ {
+			l.Lock()
+			l.Lock()
+			for len(buf) == capacity {
+				notFull.Await()
+			}
+			if got := l.HoldCount(); got != 2 {
+				t.Errorf("got %v, want %v", got, 2)
+			}
+			buf = append(buf, i)
+			notEmpty.Signal()
+			l.Unlock()
+			l.Unlock()
+		}
+	}()
+
+	for i := 0; i < items; i++ {
+		l.Lock()
+		for len(buf) == 0 {
+			notEmpty.Await()
+		}
+		got := buf[0]
+		buf = buf[1:]
+		notFull.SignalAll()
+		l.Unlock()
+
+		if got != i {
+			t.Fatalf("got %v, want %v", got, i)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package locks
+
+// CurrentNode returns the NUMA node of the CPU the calling goroutine runs
+// on, or 0 where that is not known. The goroutine may be moved to another
+// CPU right after, so the result is only a hint.
+func CurrentNode() int {
+	return currentNode()
+}
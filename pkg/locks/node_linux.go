@@ -0,0 +1,19 @@
+//go:build linux && (amd64 || arm64)
+
+package locks
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func currentNode() int {
+	var cpu, node uint32
+
+	_, _, errno := syscall.RawSyscall(sysGetcpu, uintptr(unsafe.Pointer(&cpu)), uintptr(unsafe.Pointer(&node)), 0)
+	if errno != 0 {
+		return 0
+	}
+
+	return int(node)
+}
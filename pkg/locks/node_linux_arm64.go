@@ -0,0 +1,3 @@
+package locks
+
+const sysGetcpu = 168
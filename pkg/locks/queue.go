@@ -0,0 +1,163 @@
+package locks
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// flag is a boolean padded to a cache line of its own.
+type flag struct {
+	v atomic.Bool
+	_ [60]byte
+}
+
+// Anderson is an array based queue lock. Waiters take consecutive slots
+// of a circular array and each spins on its own slot, so a release only
+// invalidates the cache line of the next waiter and the lock is granted
+// in FIFO order.
+//
+// The array has a fixed capacity which must be at least the number of
+// goroutines that may hold or wait for the lock at once, exceeding it
+// breaks mutual exclusion.
+type Anderson struct {
+	flags []flag
+	tail  atomic.Uint64
+
+	// slot of the current holder, only touched while holding the lock
+	slot uint64
+}
+
+func NewAnderson(capacity int) *Anderson {
+	if capacity < 1 {
+		panic("locks: anderson lock capacity must be positive")
+	}
+
+	l := &Anderson{
+		flags: make([]flag, capacity),
+	}
+	l.flags[0].v.Store(true)
+
+	return l
+}
+
+func (l *Anderson) Lock() {
+	slot := (l.tail.Add(1) - 1) % uint64(len(l.flags))
+
+	var s spin.Spinner
+	for !l.flags[slot].v.Load() {
+		s.Spin()
+	}
+
+	l.slot = slot
+}
+
+func (l *Anderson) Unlock() {
+	slot := l.slot
+
+	l.flags[slot].v.Store(false)
+	l.flags[(slot+1)%uint64(len(l.flags))].v.Store(true)
+}
+
+type clhNode struct {
+	locked atomic.Bool
+}
+
+// CLH is a queue lock where waiters form an implicit linked list, each
+// spinning on the node of its predecessor. It is granted in FIFO order
+// and needs no capacity up front. The zero value is an unlocked lock.
+//
+// Once a goroutine holds the lock the node of its predecessor is no
+// longer used by anyone and is recycled for later waiters.
+type CLH struct {
+	tail atomic.Pointer[clhNode]
+	pool sync.Pool
+
+	// node of the current holder, only touched while holding the lock
+	node *clhNode
+}
+
+func (l *CLH) Lock() {
+	node, _ := l.pool.Get().(*clhNode)
+	if node == nil {
+		node = &clhNode{}
+	}
+	node.locked.Store(true)
+
+	// a nil predecessor means the lock was free
+	if pred := l.tail.Swap(node); pred != nil {
+		var s spin.Spinner
+		for pred.locked.Load() {
+			s.Spin()
+		}
+
+		l.pool.Put(pred)
+	}
+
+	l.node = node
+}
+
+func (l *CLH) Unlock() {
+	l.node.locked.Store(false)
+}
+
+type mcsNode struct {
+	locked atomic.Bool
+	next   atomic.Pointer[mcsNode]
+}
+
+// MCS is a queue lock where waiters form an explicit linked list, each
+// spinning on its own node until its predecessor hands the lock over. It
+// is granted in FIFO order and, unlike CLH, every waiter spins on memory
+// it owns. The zero value is an unlocked lock.
+type MCS struct {
+	tail atomic.Pointer[mcsNode]
+	pool sync.Pool
+
+	// node of the current holder, only touched while holding the lock
+	node *mcsNode
+}
+
+func (l *MCS) Lock() {
+	node, _ := l.pool.Get().(*mcsNode)
+	if node == nil {
+		node = &mcsNode{}
+	}
+	node.next.Store(nil)
+	node.locked.Store(true)
+
+	if pred := l.tail.Swap(node); pred != nil {
+		pred.next.Store(node)
+
+		var s spin.Spinner
+		for node.locked.Load() {
+			s.Spin()
+		}
+	}
+
+	l.node = node
+}
+
+func (l *MCS) Unlock() {
+	node := l.node
+
+	next := node.next.Load()
+	if next == nil {
+		// no one is waiting
+		if l.tail.CompareAndSwap(node, nil) {
+			l.pool.Put(node)
+			return
+		}
+
+		// someone is about to link themselves after us
+		var s spin.Spinner
+		for next == nil {
+			s.Spin()
+			next = node.next.Load()
+		}
+	}
+
+	next.locked.Store(false)
+	l.pool.Put(node)
+}
@@ -0,0 +1,121 @@
+package locks
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// TAS is a test-and-set spin lock. Every waiter keeps swapping the lock
+// state, which invalidates the cache line for everyone else on every
+// attempt. The zero value is an unlocked lock.
+type TAS struct {
+	state atomic.Bool
+}
+
+func (l *TAS) Lock() {
+	var s spin.Spinner
+	for l.state.Swap(true) {
+		s.Spin()
+	}
+}
+
+func (l *TAS) Unlock() {
+	l.state.Store(false)
+}
+
+// TTAS is a test-and-test-and-set spin lock. Waiters spin reading their
+// cached copy of the state and only swap it once it looks free. The zero
+// value is an unlocked lock.
+type TTAS struct {
+	state atomic.Bool
+}
+
+func (l *TTAS) Lock() {
+	var s spin.Spinner
+	for {
+		for l.state.Load() {
+			s.Spin()
+		}
+
+		if !l.state.Swap(true) {
+			return
+		}
+	}
+}
+
+func (l *TTAS) Unlock() {
+	l.state.Store(false)
+}
+
+const (
+	defaultMinDelay = time.Microsecond
+	defaultMaxDelay = 100 * time.Microsecond
+)
+
+// backoff sleeps for a random delay whose limit doubles on every call.
+type backoff struct {
+	limit    time.Duration
+	maxDelay time.Duration
+}
+
+// newBackoff returns a backoff, zero delays are replaced by the defaults.
+func newBackoff(minDelay, maxDelay time.Duration) backoff {
+	if minDelay <= 0 {
+		minDelay = defaultMinDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxDelay
+	}
+
+	return backoff{limit: minDelay, maxDelay: maxDelay}
+}
+
+func (b *backoff) backoff() {
+	time.Sleep(time.Duration(rand.Int63n(int64(b.limit)) + 1))
+	if b.limit < b.maxDelay {
+		b.limit *= 2
+	}
+}
+
+// Backoff is a test-and-test-and-set spin lock which backs off for a
+// random, exponentially growing delay whenever it loses a race for the
+// lock, keeping the contention on it low. The zero value is an unlocked
+// lock with default delays.
+type Backoff struct {
+	state    atomic.Bool
+	minDelay time.Duration
+	maxDelay time.Duration
+}
+
+// NewBackoff returns a lock which initially backs off for up to minDelay,
+// doubling the limit on every failed attempt up to maxDelay.
+func NewBackoff(minDelay, maxDelay time.Duration) *Backoff {
+	return &Backoff{
+		minDelay: minDelay,
+		maxDelay: maxDelay,
+	}
+}
+
+func (l *Backoff) Lock() {
+	b := newBackoff(l.minDelay, l.maxDelay)
+
+	var s spin.Spinner
+	for {
+		for l.state.Load() {
+			s.Spin()
+		}
+
+		if !l.state.Swap(true) {
+			return
+		}
+
+		b.backoff()
+	}
+}
+
+func (l *Backoff) Unlock() {
+	l.state.Store(false)
+}
@@ -0,0 +1,346 @@
+package locks
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// TimeoutLocker is a lock whose waiters can give up.
+type TimeoutLocker interface {
+	sync.Locker
+
+	// TryLock acquires the lock if it is free and reports whether it did.
+	TryLock() bool
+
+	// TryLockFor waits up to d for the lock and reports whether it was
+	// acquired.
+	TryLockFor(d time.Duration) bool
+
+	// LockContext waits for the lock until ctx is done, in which case it
+	// returns the error of ctx.
+	LockContext(ctx context.Context) error
+}
+
+var (
+	_ TimeoutLocker = (*TOLock)(nil)
+	_ TimeoutLocker = (*Composite)(nil)
+)
+
+// patience tells a waiter when to give up, the zero value never does.
+type patience struct {
+	deadline time.Time
+	done     <-chan struct{}
+}
+
+func patienceFor(d time.Duration) patience {
+	return patience{deadline: time.Now().Add(d)}
+}
+
+func (p patience) expired() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+	}
+
+	return !p.deadline.IsZero() && !time.Now().Before(p.deadline)
+}
+
+type toNode struct {
+	pred atomic.Pointer[toNode]
+}
+
+// toAvailable is stored as the predecessor of a node whose owner
+// released the lock.
+var toAvailable = &toNode{}
+
+// TOLock is a CLH queue lock whose waiters can abandon the queue. A node
+// of a waiter which gave up points to its predecessor so that its
+// successor can skip it and spin on the predecessor instead. The zero
+// value is an unlocked lock.
+//
+// Abandoned nodes may still be referenced by other waiters so nodes are
+// never recycled.
+//
+// This is adapted from The Art of Multiprocessor Programming, 7.6.
+type TOLock struct {
+	tail atomic.Pointer[toNode]
+
+	// node of the current holder, only touched while holding the lock
+	node *toNode
+}
+
+func (l *TOLock) Lock() {
+	l.acquire(patience{})
+}
+
+// TryLock acquires the lock if it is free and reports whether it did.
+func (l *TOLock) TryLock() bool {
+	return l.acquire(patienceFor(0))
+}
+
+// TryLockFor waits up to d for the lock and reports whether it was
+// acquired.
+func (l *TOLock) TryLockFor(d time.Duration) bool {
+	return l.acquire(patienceFor(d))
+}
+
+// LockContext waits for the lock until ctx is done, in which case it
+// returns the error of ctx.
+func (l *TOLock) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !l.acquire(patience{done: ctx.Done()}) {
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func (l *TOLock) Unlock() {
+	node := l.node
+
+	// let the successor, if any, know that the lock is free
+	if !l.tail.CompareAndSwap(node, nil) {
+		node.pred.Store(toAvailable)
+	}
+}
+
+func (l *TOLock) acquire(p patience) bool {
+	node := &toNode{}
+
+	pred := l.tail.Swap(node)
+	if pred == nil || pred.pred.Load() == toAvailable {
+		l.node = node
+		return true
+	}
+
+	var s spin.Spinner
+	for !p.expired() {
+		predPred := pred.pred.Load()
+		if predPred == toAvailable {
+			l.node = node
+			return true
+		}
+
+		// pred gave up, wait for its predecessor instead
+		if predPred != nil {
+			pred = predPred
+		}
+
+		s.Spin()
+	}
+
+	// give up - if we are the last in the queue simply leave it, otherwise
+	// point our successor to our predecessor
+	if !l.tail.CompareAndSwap(node, pred) {
+		node.pred.Store(pred)
+	}
+
+	return false
+}
+
+type compositeState = int32
+
+const (
+	nodeFree compositeState = iota
+	nodeWaiting
+	nodeReleased
+	nodeAborted
+)
+
+type compositeNode struct {
+	state atomic.Int32
+
+	// pred is set when the owner gives up while waiting
+	pred atomic.Pointer[compositeNode]
+
+	_ [48]byte
+}
+
+// compositeTail is never modified once stored, comparing tails by
+// identity tells whether the tail changed even if it points to a node
+// which got recycled in the meantime.
+type compositeTail struct {
+	node *compositeNode
+}
+
+// Composite is a queue lock with a small fixed pool of nodes. A goroutine
+// first grabs a random free node from the pool, backing off while they
+// are all taken, and then queues it like in TOLock. Only as many
+// goroutines as there are nodes ever spin in the queue, the rest back off,
+// which keeps the lock cheap under both low and high contention.
+//
+// This is adapted from The Art of Multiprocessor Programming, 7.7.
+type Composite struct {
+	nodes    []compositeNode
+	tail     atomic.Pointer[compositeTail]
+	minDelay time.Duration
+	maxDelay time.Duration
+
+	// node of the current holder, only touched while holding the lock
+	node *compositeNode
+}
+
+// NewComposite returns a composite lock with size queue nodes, which back
+// off between minDelay and maxDelay when they are all in use. Zero delays
+// select the defaults.
+func NewComposite(size int, minDelay, maxDelay time.Duration) *Composite {
+	if size < 1 {
+		panic("locks: composite lock size must be positive")
+	}
+
+	l := &Composite{
+		nodes:    make([]compositeNode, size),
+		minDelay: minDelay,
+		maxDelay: maxDelay,
+	}
+	l.tail.Store(&compositeTail{})
+
+	return l
+}
+
+func (l *Composite) Lock() {
+	l.acquire(patience{})
+}
+
+// TryLock acquires the lock if it is free and reports whether it did.
+func (l *Composite) TryLock() bool {
+	return l.acquire(patienceFor(0))
+}
+
+// TryLockFor waits up to d for the lock and reports whether it was
+// acquired.
+func (l *Composite) TryLockFor(d time.Duration) bool {
+	return l.acquire(patienceFor(d))
+}
+
+// LockContext waits for the lock until ctx is done, in which case it
+// returns the error of ctx.
+func (l *Composite) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !l.acquire(patience{done: ctx.Done()}) {
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func (l *Composite) Unlock() {
+	l.node.state.Store(nodeReleased)
+}
+
+func (l *Composite) acquire(p patience) bool {
+	node, ok := l.acquireNode(p)
+	if !ok {
+		return false
+	}
+
+	pred, ok := l.splice(node, p)
+	if !ok {
+		return false
+	}
+
+	if !l.waitForPredecessor(node, pred, p) {
+		return false
+	}
+
+	l.node = node
+	return true
+}
+
+// acquireNode grabs a random node from the pool. A node which is still
+// the tail of the queue after its owner left can be taken over by
+// removing it from the queue.
+//
+// Unlike the original a new node is picked after every backoff, a node
+// released with no one queued behind it stays taken until the next
+// waiter walks past it, which would otherwise never happen if that
+// waiter is us.
+func (l *Composite) acquireNode(p patience) (*compositeNode, bool) {
+	b := newBackoff(l.minDelay, l.maxDelay)
+
+	for {
+		node := &l.nodes[rand.Intn(len(l.nodes))]
+		if node.state.CompareAndSwap(nodeFree, nodeWaiting) {
+			return node, true
+		}
+
+		tail := l.tail.Load()
+		state := node.state.Load()
+		if (state == nodeAborted || state == nodeReleased) && node == tail.node {
+			var pred *compositeNode
+			if state == nodeAborted {
+				pred = node.pred.Load()
+			}
+
+			if l.tail.CompareAndSwap(tail, &compositeTail{node: pred}) {
+				node.state.Store(nodeWaiting)
+				return node, true
+			}
+		}
+
+		if p.expired() {
+			return nil, false
+		}
+
+		b.backoff()
+	}
+}
+
+// splice appends node to the queue and returns its predecessor.
+func (l *Composite) splice(node *compositeNode, p patience) (*compositeNode, bool) {
+	fresh := &compositeTail{node: node}
+
+	for {
+		tail := l.tail.Load()
+		if l.tail.CompareAndSwap(tail, fresh) {
+			return tail.node, true
+		}
+
+		if p.expired() {
+			node.state.Store(nodeFree)
+			return nil, false
+		}
+	}
+}
+
+// waitForPredecessor spins until pred releases the lock, freeing the
+// nodes of any predecessors which gave up on the way.
+func (l *Composite) waitForPredecessor(node, pred *compositeNode, p patience) bool {
+	if pred == nil {
+		return true
+	}
+
+	var s spin.Spinner
+	for {
+		switch pred.state.Load() {
+		case nodeReleased:
+			pred.state.Store(nodeFree)
+			return true
+		case nodeAborted:
+			skipped := pred
+			pred = pred.pred.Load()
+			skipped.state.Store(nodeFree)
+			continue
+		}
+
+		if p.expired() {
+			node.pred.Store(pred)
+			node.state.Store(nodeAborted)
+			return false
+		}
+
+		s.Spin()
+	}
+}
@@ -1,7 +1,12 @@
 package queue
 
 import (
+	"sync"
 	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+	"github.com/tangledbytes/godc/pkg/counter"
+	"github.com/tangledbytes/godc/pkg/reclaim"
 )
 
 type Node[T any] struct {
@@ -13,9 +18,49 @@ type Queue[T any] struct {
 	head *atomic.Pointer[Node[T]]
 	tail *atomic.Pointer[Node[T]]
 	len  atomic.Int64
+
+	// striped replaces len when the queue is created WithStripedLen
+	striped *counter.Striped
+
+	// epochs and nodes are only set when the queue is created
+	// WithRecycledNodes
+	epochs *reclaim.EpochDomain[Node[T]]
+	nodes  *sync.Pool
+}
+
+// Option configures a queue.
+type Option func(*config)
+
+type config struct {
+	stripedLen    bool
+	recycledNodes bool
+}
+
+// WithStripedLen makes the queue track its length with a striped counter
+// instead of a single atomic integer, which takes contention off Push and
+// Pop at the cost of Len no longer being an exact instantaneous size.
+func WithStripedLen() Option {
+	return func(c *config) {
+		c.stripedLen = true
+	}
+}
+
+// WithRecycledNodes makes the queue reuse the nodes of popped items for
+// later pushes instead of allocating a new one every time. Every Push, Pop
+// and Peek then pins an epoch so that nodes are only reused once no one
+// can be looking at them.
+func WithRecycledNodes() Option {
+	return func(c *config) {
+		c.recycledNodes = true
+	}
 }
 
-func New[T any]() *Queue[T] {
+func New[T any](opts ...Option) *Queue[T] {
+	cfg := config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	sen := &Node[T]{}
 
 	head := &atomic.Pointer[Node[T]]{}
@@ -24,29 +69,86 @@ func New[T any]() *Queue[T] {
 	tail := &atomic.Pointer[Node[T]]{}
 	tail.Store(sen)
 
-	return &Queue[T]{
+	q := &Queue[T]{
 		head: head,
 		tail: tail,
 	}
+
+	if cfg.stripedLen {
+		q.striped = counter.NewStriped()
+	}
+
+	if cfg.recycledNodes {
+		q.nodes = &sync.Pool{}
+		q.epochs = reclaim.NewEpochDomain(func(n *Node[T]) {
+			var def T
+
+			n.Next.Store(nil)
+			n.Data = def
+			q.nodes.Put(n)
+		})
+	}
+
+	return q
 }
 
 func (q *Queue[T]) Len() int64 {
+	if q.striped != nil {
+		return q.striped.Sum()
+	}
+
 	return q.len.Load()
 }
 
+func (q *Queue[T]) addLen(delta int64) {
+	if q.striped != nil {
+		q.striped.Add(delta)
+		return
+	}
+
+	q.len.Add(delta)
+}
+
+// pin pins the epoch of a queue created WithRecycledNodes, for any other
+// queue it does nothing.
+func (q *Queue[T]) pin() (reclaim.EpochGuard[Node[T]], bool) {
+	if q.epochs == nil {
+		return reclaim.EpochGuard[Node[T]]{}, false
+	}
+
+	return q.epochs.Pin(), true
+}
+
+func (q *Queue[T]) newNode(data T) *Node[T] {
+	if q.nodes != nil {
+		if n, ok := q.nodes.Get().(*Node[T]); ok {
+			n.Data = data
+			return n
+		}
+	}
+
+	return &Node[T]{Data: data}
+}
+
 func (q *Queue[T]) Push(data T) {
-	new := &Node[T]{Data: data}
+	if guard, ok := q.pin(); ok {
+		defer guard.Unpin()
+	}
+
+	new := q.newNode(data)
 
 	for {
 		tail := q.tail.Load()
 		next := tail.Next.Load()
+		sched.Yield("queue: push loaded tail")
 
 		if tail == q.tail.Load() {
 			// ideal case - tail.Next is nil
 			if next == nil {
 				if tail.Next.CompareAndSwap(nil, new) {
+					sched.Yield("queue: push linked node")
 					q.tail.CompareAndSwap(tail, new)
-					q.len.Add(1)
+					q.addLen(1)
 					return
 				}
 				// something changed the value of tail.Next
@@ -65,10 +167,16 @@ func (q *Queue[T]) Push(data T) {
 func (q *Queue[T]) Pop() (T, bool) {
 	var def T
 
+	guard, pinned := q.pin()
+	if pinned {
+		defer guard.Unpin()
+	}
+
 	for {
 		head := q.head.Load()
 		tail := q.tail.Load()
 		next := head.Next.Load()
+		sched.Yield("queue: pop loaded head")
 
 		// if the head is the same as when we started
 		// then we can try to pop
@@ -85,8 +193,15 @@ func (q *Queue[T]) Pop() (T, bool) {
 				q.tail.CompareAndSwap(tail, next)
 			} else {
 				if q.head.CompareAndSwap(head, next) {
-					q.len.Add(-1)
-					return next.Data, true
+					q.addLen(-1)
+
+					// next is the new sentinel, the old one is unreachable
+					data := next.Data
+					if pinned {
+						guard.Retire(head)
+					}
+
+					return data, true
 				}
 			}
 		}
@@ -100,6 +215,10 @@ func (q *Queue[T]) Pop() (T, bool) {
 func (q *Queue[T]) Peek() (T, bool) {
 	var def T
 
+	if guard, ok := q.pin(); ok {
+		defer guard.Unpin()
+	}
+
 	head := q.head.Load()
 	next := head.Next.Load()
 
@@ -163,3 +163,36 @@ func Benchmark_Queue_Pop(b *testing.B) {
 		})
 	})
 }
+
+func Benchmark_Queue_PushPop(b *testing.B) {
+	opts := map[string][]Option{
+		"allocated": nil,
+		"recycled":  {WithRecycledNodes()},
+	}
+
+	for name, opts := range opts {
+		b.Run("single threaded - "+name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			q := New[int](opts...)
+
+			for i := 0; i < b.N; i++ {
+				q.Push(i)
+				q.Pop()
+			}
+		})
+
+		b.Run("multi threaded - "+name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			q := New[int](opts...)
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					q.Push(1)
+					q.Pop()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+)
+
+const (
+	fuzzGoroutines = 4
+
+	// fuzzMaxOps keeps the histories small enough to check quickly
+	fuzzMaxOps = 64
+)
+
+type fuzzOpKind byte
+
+const (
+	fuzzPush fuzzOpKind = iota
+	fuzzPop
+	fuzzPeek
+	fuzzLen
+)
+
+type fuzzOp struct {
+	goroutine int
+	kind      fuzzOpKind
+	value     int
+}
+
+// decodeFuzzProgram decodes the fuzzer's input into the options of the
+// queue and a program. The first byte picks the options, then every pair
+// of bytes is an operation: the low two bits of the first byte pick the
+// operation, the next two the goroutine running it and the second byte is
+// the pushed value.
+func decodeFuzzProgram(data []byte) ([]Option, []fuzzOp) {
+	var opts []Option
+	if len(data) > 0 {
+		switch data[0] % 3 {
+		case 1:
+			opts = append(opts, WithStripedLen())
+		case 2:
+			opts = append(opts, WithRecycledNodes())
+		}
+		data = data[1:]
+	}
+
+	var ops []fuzzOp
+	for i := 0; i+1 < len(data) && len(ops) < fuzzMaxOps; i += 2 {
+		ops = append(ops, fuzzOp{
+			goroutine: int(data[i]>>2) % fuzzGoroutines,
+			kind:      fuzzOpKind(data[i] & 3),
+			value:     int(data[i+1]),
+		})
+	}
+
+	return opts, ops
+}
+
+func Fuzz_Queue(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 0, 2, 1, 0, 2, 0, 3, 0})
+	f.Add([]byte{1, 0, 1, 4, 2, 9, 0, 13, 0, 1, 0, 5, 0})
+	f.Add([]byte{2, 0, 1, 4, 2, 8, 3, 12, 4, 1, 0, 5, 0, 9, 0, 13, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts, ops := decodeFuzzProgram(data)
+
+		t.Run("sequential", func(t *testing.T) {
+			q := New[int](opts...)
+
+			var model []int
+			for _, op := range ops {
+				switch op.kind {
+				case fuzzPush:
+					q.Push(op.value)
+					model = append(model, op.value)
+				case fuzzPop:
+					got, ok := q.Pop()
+					if ok != (len(model) > 0) {
+						t.Fatalf("got %v, want %v", ok, len(model) > 0)
+					}
+					if ok {
+						if got != model[0] {
+							t.Fatalf("got %v, want %v", got, model[0])
+						}
+						model = model[1:]
+					}
+				case fuzzPeek:
+					got, ok := q.Peek()
+					if ok != (len(model) > 0) {
+						t.Fatalf("got %v, want %v", ok, len(model) > 0)
+					}
+					if ok && got != model[0] {
+						t.Fatalf("got %v, want %v", got, model[0])
+					}
+				case fuzzLen:
+					if got := q.Len(); got != int64(len(model)) {
+						t.Fatalf("got %v, want %v", got, len(model))
+					}
+				}
+			}
+		})
+
+		t.Run("concurrent", func(t *testing.T) {
+			type input = lincheck.ValueInput[int]
+			type output = lincheck.ValueOutput[int]
+
+			q := New[int](opts...)
+			r := lincheck.NewRecorder[input, output]()
+
+			programs := make([][]fuzzOp, fuzzGoroutines)
+			pushes := 0
+			for _, op := range ops {
+				programs[op.goroutine] = append(programs[op.goroutine], op)
+				if op.kind == fuzzPush {
+					pushes++
+				}
+			}
+
+			var wg sync.WaitGroup
+			for g, program := range programs {
+				wg.Add(1)
+				go func(g int, program []fuzzOp) {
+					defer wg.Done()
+
+					for _, op := range program {
+						switch op.kind {
+						case fuzzPush:
+							value := op.value
+							r.Record(g, input{Op: lincheck.Push, Value: value}, func() output {
+								q.Push(value)
+								return output{}
+							})
+						case fuzzPop:
+							r.Record(g, input{Op: lincheck.Pop}, func() output {
+								data, ok := q.Pop()
+								return output{Value: data, Ok: ok}
+							})
+						case fuzzPeek:
+							r.Record(g, input{Op: lincheck.Peek}, func() output {
+								data, ok := q.Peek()
+								return output{Value: data, Ok: ok}
+							})
+						case fuzzLen:
+							// the length is only exact once the queue is
+							// quiescent, meanwhile it is bounded by the pushes
+							if n := q.Len(); n < -int64(pushes) || n > int64(pushes) {
+								t.Errorf("got length %v, want at most %v", n, pushes)
+							}
+						}
+					}
+				}(g, program)
+			}
+			wg.Wait()
+
+			// drain what is left so that lost items show up in the history
+			length := q.Len()
+			drained := int64(0)
+			for {
+				out := r.Record(fuzzGoroutines, input{Op: lincheck.Pop}, func() output {
+					data, ok := q.Pop()
+					return output{Value: data, Ok: ok}
+				})
+				if !out.Ok {
+					break
+				}
+				drained++
+			}
+
+			if length != drained {
+				t.Errorf("got length %v, want %v", length, drained)
+			}
+
+			if !lincheck.Check(lincheck.QueueModel[int](), r.History()) {
+				t.Errorf("history is not linearizable: %v", r.History())
+			}
+		})
+	})
+}
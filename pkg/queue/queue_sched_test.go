@@ -0,0 +1,91 @@
+//go:build godc_sched
+
+package queue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+func Test_Queue_Schedules(t *testing.T) {
+	type input = lincheck.ValueInput[int]
+	type output = lincheck.ValueOutput[int]
+
+	opts := map[string][]Option{
+		"atomic":   nil,
+		"recycled": {WithRecycledNodes()},
+	}
+
+	for name, opts := range opts {
+		t.Run(name, func(t *testing.T) {
+			// two producers race on the tail while a consumer races them on
+			// the head of a queue holding a single item
+			program := func() ([]func(), func() error) {
+				q := New[int](opts...)
+				q.Push(0)
+
+				r := lincheck.NewRecorder[input, output]()
+				r.Record(0, input{Op: lincheck.Push, Value: 0}, func() output {
+					return output{}
+				})
+
+				push := func(client, value int) func() {
+					return func() {
+						r.Record(client, input{Op: lincheck.Push, Value: value}, func() output {
+							q.Push(value)
+							return output{}
+						})
+					}
+				}
+
+				popped := 0
+				pop := func() {
+					for i := 0; i < 2; i++ {
+						out := r.Record(3, input{Op: lincheck.Pop}, func() output {
+							data, ok := q.Pop()
+							return output{Value: data, Ok: ok}
+						})
+						if out.Ok {
+							popped++
+						}
+					}
+				}
+
+				return []func(){push(1, 1), push(2, 2), pop}, func() error {
+					if got, want := q.Len(), int64(3-popped); got != want {
+						return fmt.Errorf("got %v, want %v", got, want)
+					}
+
+					// whatever is left must come out too, lost items only
+					// show up in the history once the queue is drained
+					for {
+						out := r.Record(4, input{Op: lincheck.Pop}, func() output {
+							data, ok := q.Pop()
+							return output{Value: data, Ok: ok}
+						})
+						if !out.Ok {
+							break
+						}
+					}
+
+					if !lincheck.Check(lincheck.QueueModel[int](), r.History()) {
+						return fmt.Errorf("history is not linearizable: %v", r.History())
+					}
+
+					return nil
+				}
+			}
+
+			if f := sched.Exhaustive(program, 8, 0); f != nil {
+				t.Errorf("%v", sched.Shrink(program, f))
+			}
+
+			if f := sched.Random(program, 1, 500); f != nil {
+				t.Errorf("%v", sched.Shrink(program, f))
+			}
+		})
+	}
+}
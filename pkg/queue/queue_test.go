@@ -1,10 +1,12 @@
 package queue
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
 
+	"github.com/tangledbytes/godc/internal/lincheck"
 	"github.com/tangledbytes/godc/internal/util"
 )
 
@@ -311,6 +313,43 @@ func Test_Queue_Len(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		opts := map[string][]Option{
+			"atomic":   nil,
+			"striped":  {WithStripedLen()},
+			"recycled": {WithRecycledNodes()},
+		}
+
+		for name, opts := range opts {
+			t.Run(name, func(t *testing.T) {
+				q := New[int](opts...)
+
+				var wg sync.WaitGroup
+				for _, data := range util.GenerateIntSeries(1, 1000) {
+					wg.Add(1)
+					go func(data int) {
+						q.Push(data)
+						wg.Done()
+					}(data)
+				}
+				wg.Wait()
+
+				for i := 0; i < 300; i++ {
+					wg.Add(1)
+					go func() {
+						q.Pop()
+						wg.Done()
+					}()
+				}
+				wg.Wait()
+
+				if got := q.Len(); got != 700 {
+					t.Errorf("got %v, want %v", got, 700)
+				}
+			})
+		}
+	})
 }
 
 func Test_Queue_PushPop(t *testing.T) {
@@ -385,6 +424,58 @@ func Test_Queue_PushPop(t *testing.T) {
 	})
 }
 
+func Test_Queue_ProducerOrder(t *testing.T) {
+	const producers, items = 4, 500
+
+	opts := map[string][]Option{
+		"atomic":   nil,
+		"striped":  {WithStripedLen()},
+		"recycled": {WithRecycledNodes()},
+	}
+
+	for _, consumers := range []int{1, 4} {
+		for name, opts := range opts {
+			t.Run(fmt.Sprintf("%v - %v consumers", name, consumers), func(t *testing.T) {
+				q := New[util.ProducerItem](opts...)
+
+				var wg sync.WaitGroup
+				for _, produced := range util.GenerateProducerItems(producers, items) {
+					wg.Add(1)
+					go func(produced []util.ProducerItem) {
+						defer wg.Done()
+
+						for _, item := range produced {
+							q.Push(item)
+						}
+					}(produced)
+				}
+
+				// consumers keep popping until every item was taken
+				var taken atomic.Int64
+				consumed := make([][]util.ProducerItem, consumers)
+				for c := range consumed {
+					wg.Add(1)
+					go func(c int) {
+						defer wg.Done()
+
+						for taken.Load() < producers*items {
+							if item, ok := q.Pop(); ok {
+								consumed[c] = append(consumed[c], item)
+								taken.Add(1)
+							}
+						}
+					}(c)
+				}
+				wg.Wait()
+
+				if err := util.VerifyProducerFIFO(consumed, producers, items); err != nil {
+					t.Errorf("%v", err)
+				}
+			})
+		}
+	}
+}
+
 func Test_Queue_Peek(t *testing.T) {
 	t.Run("single threaded", func(t *testing.T) {
 		type test struct {
@@ -446,3 +537,103 @@ func iterQueue[T any](node *Node[T]) chan T {
 
 	return ch
 }
+
+func Test_Queue_RecycledNodes(t *testing.T) {
+	const producers, consumers, items = 4, 4, 5000
+
+	q := New[int](WithRecycledNodes())
+
+	// consumers keep popping while producers push, so that nodes are
+	// recycled while others may still be reading them
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+
+			for i := 0; i < items; i++ {
+				q.Push(p*items + i)
+			}
+		}(p)
+	}
+
+	seen := make([]atomic.Int32, producers*items)
+	var popped atomic.Int64
+
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for popped.Load() < producers*items {
+				data, ok := q.Pop()
+				if !ok {
+					continue
+				}
+
+				seen[data].Add(1)
+				popped.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for data := range seen {
+		if got := seen[data].Load(); got != 1 {
+			t.Errorf("got %v popped %v times, want once", data, got)
+		}
+	}
+
+	if got := q.Len(); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+}
+
+func Test_Queue_Linearizable(t *testing.T) {
+	const goroutines, ops = 4, 100
+
+	type input = lincheck.ValueInput[int]
+	type output = lincheck.ValueOutput[int]
+
+	opts := map[string][]Option{
+		"atomic":   nil,
+		"striped":  {WithStripedLen()},
+		"recycled": {WithRecycledNodes()},
+	}
+
+	for name, opts := range opts {
+		t.Run(name, func(t *testing.T) {
+			q := New[int](opts...)
+			r := lincheck.NewRecorder[input, output]()
+
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+
+					for i := 0; i < ops; i++ {
+						if (g+i)%2 == 0 {
+							value := g*ops + i
+							r.Record(g, input{Op: lincheck.Push, Value: value}, func() output {
+								q.Push(value)
+								return output{}
+							})
+							continue
+						}
+
+						r.Record(g, input{Op: lincheck.Pop}, func() output {
+							data, ok := q.Pop()
+							return output{Value: data, Ok: ok}
+						})
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			if !lincheck.Check(lincheck.QueueModel[int](), r.History()) {
+				t.Errorf("history is not linearizable")
+			}
+		})
+	}
+}
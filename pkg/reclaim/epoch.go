@@ -0,0 +1,182 @@
+// Package reclaim provides safe memory reclamation for lock-free data
+// structures which want to reuse their nodes instead of leaving every one
+// of them to the garbage collector.
+//
+// A node unlinked from a lock-free structure may still be read by
+// goroutines which loaded a pointer to it before. Reusing it right away
+// would let them see it change under their feet and, worse, let their
+// CASes succeed on a node which is no longer what they think it is. The
+// schemes in this package hold on to retired nodes until no goroutine can
+// be looking at them any more and only then hand them to a free function,
+// which typically puts them into a pool.
+package reclaim
+
+import (
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+// epochRetireThreshold is how many nodes are retired to a slot between
+// attempts to advance the epoch.
+const epochRetireThreshold = 64
+
+// epochSlot is where goroutines announce their pins and keep the nodes
+// they retired. Pins and nodes are counted separately for the last three
+// epochs, which are all that can be in use at once.
+type epochSlot[T any] struct {
+	pins [3]atomic.Int64
+
+	mu       sync.Mutex
+	retired  [3][]*T
+	sinceTry int
+
+	_ [64]byte
+}
+
+// EpochDomain reclaims nodes using epoch based reclamation. Goroutines pin
+// the domain for as long as they access a structure and retire the nodes
+// they unlink while pinned. A retired node is freed once the global epoch
+// advanced twice, which it only does when no goroutine is pinned to an
+// epoch older than the current one, so no one can still be holding it.
+//
+// Pinning is cheap, a couple of atomic operations on a slot shared with
+// few others, but a single goroutine staying pinned keeps every node
+// retired since from being freed.
+//
+// Go does not expose which P a goroutine runs on, so instead of per-P
+// epochs there are as many slots as CPUs and a goroutine picks one at
+// random when pinning.
+//
+// This is adapted from Fraser, Practical Lock-Freedom, chapter 5.
+type EpochDomain[T any] struct {
+	epoch atomic.Uint64
+	slots []epochSlot[T]
+	free  func(*T)
+
+	// advancing serialises advancing the epoch with collecting the nodes
+	// it frees. Otherwise an unpinned caller could stall between the two
+	// while the epoch moves on again, and collect nodes retired since
+	// into the same list
+	advancing sync.Mutex
+}
+
+// NewEpochDomain returns a domain which passes nodes to free once they can
+// be safely reused.
+func NewEpochDomain[T any](free func(*T)) *EpochDomain[T] {
+	return &EpochDomain[T]{
+		// more slots than CPUs cannot all be contended at once
+		slots: make([]epochSlot[T], 1<<bits.Len(uint(runtime.NumCPU()-1))),
+		free:  free,
+	}
+}
+
+// EpochGuard is a pin of an EpochDomain. It must be unpinned by the
+// goroutine which pinned it.
+type EpochGuard[T any] struct {
+	domain *EpochDomain[T]
+	slot   *epochSlot[T]
+	epoch  uint64
+}
+
+// Pin pins the calling goroutine to the current epoch. Nodes reachable
+// from the structure while it is pinned are not freed until it unpins.
+func (d *EpochDomain[T]) Pin() EpochGuard[T] {
+	slot := &d.slots[rand.Uint32()&uint32(len(d.slots)-1)]
+
+	for {
+		epoch := d.epoch.Load()
+		sched.Yield("reclaim: epoch pin loaded epoch")
+		slot.pins[epoch%3].Add(1)
+
+		// the epoch may have moved on before the pin was visible, in which
+		// case it would not hold anything back
+		if d.epoch.Load() == epoch {
+			return EpochGuard[T]{domain: d, slot: slot, epoch: epoch}
+		}
+
+		slot.pins[epoch%3].Add(-1)
+	}
+}
+
+// Unpin releases the pin.
+func (g EpochGuard[T]) Unpin() {
+	g.slot.pins[g.epoch%3].Add(-1)
+}
+
+// Retire hands a node which was unlinked from the structure to the domain,
+// which frees it once no goroutine can be accessing it.
+func (g EpochGuard[T]) Retire(node *T) {
+	d := g.domain
+	epoch := d.epoch.Load()
+
+	slot := g.slot
+	slot.mu.Lock()
+	slot.retired[epoch%3] = append(slot.retired[epoch%3], node)
+	slot.sinceTry++
+	try := slot.sinceTry >= epochRetireThreshold
+	if try {
+		slot.sinceTry = 0
+	}
+	slot.mu.Unlock()
+
+	if try {
+		d.TryAdvance()
+	}
+}
+
+// TryAdvance advances the epoch unless someone is still pinned to the
+// previous one, and frees the nodes retired two epochs ago if it did. It
+// reports whether the epoch was advanced.
+//
+// Retire calls it on its own every so often, calling it directly is only
+// needed to free nodes sooner. It gives up right away if someone else is
+// advancing the epoch.
+func (d *EpochDomain[T]) TryAdvance() bool {
+	if !d.advancing.TryLock() {
+		return false
+	}
+
+	epoch := d.epoch.Load()
+	prev := (epoch + 2) % 3
+
+	// pins to older epochs were gone before the epoch got here. Pins
+	// showing up while the slots are read are backed out again, since the
+	// epoch did not match
+	for i := range d.slots {
+		if d.slots[i].pins[prev].Load() != 0 {
+			d.advancing.Unlock()
+			return false
+		}
+	}
+
+	// only TryAdvance moves the epoch and it holds advancing
+	sched.Yield("reclaim: epoch advance checked pins")
+	d.epoch.Store(epoch + 1)
+
+	// nodes retired in the previous epoch were unlinked before anyone
+	// pinned to the current one, and everyone pinned before is gone
+	var garbage []*T
+	for i := range d.slots {
+		slot := &d.slots[i]
+
+		slot.mu.Lock()
+		garbage = append(garbage, slot.retired[prev]...)
+		for j := range slot.retired[prev] {
+			slot.retired[prev][j] = nil
+		}
+		slot.retired[prev] = slot.retired[prev][:0]
+		slot.mu.Unlock()
+	}
+	d.advancing.Unlock()
+
+	for _, node := range garbage {
+		d.free(node)
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package reclaim
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type node struct {
+	value int
+	freed atomic.Bool
+}
+
+func Test_EpochDomain_Retire(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		freed := 0
+		d := NewEpochDomain(func(n *node) {
+			n.freed.Store(true)
+			freed++
+		})
+
+		n := &node{}
+
+		reader := d.Pin()
+		writer := d.Pin()
+		writer.Retire(n)
+		writer.Unpin()
+
+		// the reader may still hold the node however often the epoch is
+		// pushed
+		for i := 0; i < 10; i++ {
+			d.TryAdvance()
+		}
+
+		if n.freed.Load() {
+			t.Fatalf("node freed while a goroutine was pinned")
+		}
+
+		reader.Unpin()
+
+		for i := 0; i < 3; i++ {
+			d.TryAdvance()
+		}
+
+		if !n.freed.Load() {
+			t.Errorf("node not freed after everyone unpinned")
+		}
+
+		if freed != 1 {
+			t.Errorf("got %v, want %v", freed, 1)
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		const goroutines, iterations = 8, 2000
+
+		var freed atomic.Int64
+		d := NewEpochDomain(func(n *node) {
+			n.freed.Store(true)
+			freed.Add(1)
+		})
+
+		// goroutines keep swapping the shared node for a new one and
+		// retiring the old one, while checking that the node they loaded
+		// is not freed for as long as they are pinned
+		var shared atomic.Pointer[node]
+		shared.Store(&node{})
+
+		// others push the epoch without being pinned themselves
+		var done atomic.Bool
+		var advancers sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			advancers.Add(1)
+			go func() {
+				defer advancers.Done()
+
+				for !done.Load() {
+					d.TryAdvance()
+				}
+			}()
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < iterations; j++ {
+					g := d.Pin()
+
+					n := shared.Load()
+					if n.freed.Load() {
+						t.Errorf("loaded a freed node")
+					}
+
+					if j%2 == 0 && shared.CompareAndSwap(n, &node{value: j}) {
+						g.Retire(n)
+					}
+
+					if n.freed.Load() {
+						t.Errorf("node freed while pinned")
+					}
+
+					g.Unpin()
+				}
+			}(i)
+		}
+		wg.Wait()
+		done.Store(true)
+		advancers.Wait()
+
+		if freed.Load() == 0 {
+			t.Errorf("no node was freed")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package reclaim
+
+import (
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+)
+
+// hazardSlot is a hazard pointer padded to a cache line of its own.
+type hazardSlot[T any] struct {
+	ptr atomic.Pointer[T]
+	_   [56]byte
+}
+
+// Hazard is a set of hazard pointers owned by a single goroutine at a
+// time, along with the nodes it retired which could not be freed yet.
+type Hazard[T any] struct {
+	domain *HazardDomain[T]
+	slots  []hazardSlot[T]
+	active atomic.Bool
+	next   *Hazard[T]
+
+	// retired is only touched by the owner
+	retired []*T
+}
+
+// HazardDomain reclaims nodes using hazard pointers. Before accessing a
+// node a goroutine publishes a pointer to it in one of its hazard slots
+// and makes sure it is still reachable. A retired node is only freed once
+// no slot points to it.
+//
+// Unlike epochs a goroutine which stalls only keeps the nodes it protects
+// from being freed. A hazard holds at most 2H+1 retired nodes, H being
+// the number of slots in the domain, so memory stays bounded however the
+// goroutines are scheduled. The price is a store and a load on every
+// node visited.
+//
+// This is adapted from Michael, Hazard Pointers: Safe Memory Reclamation
+// for Lock-Free Objects.
+type HazardDomain[T any] struct {
+	head    atomic.Pointer[Hazard[T]]
+	records atomic.Int64
+	slots   int
+	free    func(*T)
+}
+
+// NewHazardDomain returns a domain whose hazards have the given number of
+// slots, which passes nodes to free once they can be safely reused.
+func NewHazardDomain[T any](slots int, free func(*T)) *HazardDomain[T] {
+	if slots < 1 {
+		panic("reclaim: hazards need at least one slot")
+	}
+
+	return &HazardDomain[T]{slots: slots, free: free}
+}
+
+// Acquire returns a hazard for the calling goroutine, reusing one released
+// before if possible. It must be released once the goroutine is done with
+// the structure.
+func (d *HazardDomain[T]) Acquire() *Hazard[T] {
+	for h := d.head.Load(); h != nil; h = h.next {
+		if !h.active.Load() && h.active.CompareAndSwap(false, true) {
+			return h
+		}
+	}
+
+	h := &Hazard[T]{
+		domain: d,
+		slots:  make([]hazardSlot[T], d.slots),
+	}
+	h.active.Store(true)
+
+	for {
+		h.next = d.head.Load()
+		sched.Yield("reclaim: hazard acquire loaded head")
+		if d.head.CompareAndSwap(h.next, h) {
+			d.records.Add(1)
+			return h
+		}
+	}
+}
+
+// Release clears the slots of the hazard and hands it back to the domain.
+// Nodes it retired which could not be freed yet are left to the next
+// goroutine acquiring it.
+func (h *Hazard[T]) Release() {
+	for i := range h.slots {
+		h.slots[i].ptr.Store(nil)
+	}
+
+	h.active.Store(false)
+}
+
+// Protect loads the node src points to and protects it in slot i. The
+// node is safe to access until the slot is overwritten or cleared.
+func (h *Hazard[T]) Protect(i int, src *atomic.Pointer[T]) *T {
+	slot := &h.slots[i].ptr
+
+	node := src.Load()
+	for {
+		slot.Store(node)
+		sched.Yield("reclaim: hazard protect set slot")
+
+		// the node may have been retired before it was protected, but then
+		// src no longer points to it
+		curr := src.Load()
+		if curr == node {
+			return node
+		}
+		node = curr
+	}
+}
+
+// Set protects node in slot i. Unlike Protect it is up to the caller to
+// check that the node was still reachable after it was set.
+func (h *Hazard[T]) Set(i int, node *T) {
+	h.slots[i].ptr.Store(node)
+}
+
+// Clear stops protecting the node in slot i.
+func (h *Hazard[T]) Clear(i int) {
+	h.slots[i].ptr.Store(nil)
+}
+
+// Retire hands a node which was unlinked from the structure to the
+// domain. Once enough nodes were retired it scans for those which can be
+// freed.
+func (h *Hazard[T]) Retire(node *T) {
+	h.retired = append(h.retired, node)
+
+	// with more than twice as many nodes retired as there are slots, a
+	// scan frees at least half of them
+	if len(h.retired) >= 2*int(h.domain.records.Load())*h.domain.slots+1 {
+		h.Scan()
+	}
+}
+
+// Scan frees the retired nodes of the hazard which no slot in the domain
+// protects.
+func (h *Hazard[T]) Scan() {
+	d := h.domain
+
+	protected := make(map[*T]struct{})
+	for r := d.head.Load(); r != nil; r = r.next {
+		for i := range r.slots {
+			if node := r.slots[i].ptr.Load(); node != nil {
+				protected[node] = struct{}{}
+			}
+		}
+	}
+
+	kept := h.retired[:0]
+	for _, node := range h.retired {
+		if _, ok := protected[node]; ok {
+			kept = append(kept, node)
+			continue
+		}
+
+		d.free(node)
+	}
+
+	for i := len(kept); i < len(h.retired); i++ {
+		h.retired[i] = nil
+	}
+	h.retired = kept
+}
@@ -0,0 +1,105 @@
+package reclaim
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_HazardDomain_Retire(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		freed := 0
+		d := NewHazardDomain(1, func(n *node) {
+			n.freed.Store(true)
+			freed++
+		})
+
+		var src atomic.Pointer[node]
+		src.Store(&node{})
+
+		reader := d.Acquire()
+		n := reader.Protect(0, &src)
+
+		writer := d.Acquire()
+		src.Store(&node{})
+		writer.Retire(n)
+
+		for i := 0; i < 10; i++ {
+			writer.Scan()
+		}
+
+		if n.freed.Load() {
+			t.Fatalf("node freed while protected")
+		}
+
+		reader.Clear(0)
+		writer.Scan()
+
+		if !n.freed.Load() {
+			t.Errorf("node not freed once no longer protected")
+		}
+
+		if freed != 1 {
+			t.Errorf("got %v, want %v", freed, 1)
+		}
+
+		reader.Release()
+		writer.Release()
+
+		// released hazards are reused
+		if h := d.Acquire(); h != reader && h != writer {
+			t.Errorf("released hazard not reused")
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		const goroutines, iterations = 8, 2000
+
+		var freed atomic.Int64
+		d := NewHazardDomain(1, func(n *node) {
+			n.freed.Store(true)
+			freed.Add(1)
+		})
+
+		var shared atomic.Pointer[node]
+		shared.Store(&node{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				h := d.Acquire()
+				defer h.Release()
+
+				for j := 0; j < iterations; j++ {
+					n := h.Protect(0, &shared)
+					if n.freed.Load() {
+						t.Errorf("protected a freed node")
+					}
+
+					if j%2 == 0 && shared.CompareAndSwap(n, &node{value: j}) {
+						h.Retire(n)
+					}
+
+					if n.freed.Load() {
+						t.Errorf("node freed while protected")
+					}
+
+					// retired nodes stay bounded by the number of slots
+					if bound := 2*int(d.records.Load())*d.slots + 1; len(h.retired) > bound {
+						t.Errorf("got %v retired nodes, want at most %v", len(h.retired), bound)
+					}
+
+					h.Clear(0)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if freed.Load() == 0 {
+			t.Errorf("no node was freed")
+		}
+	})
+}
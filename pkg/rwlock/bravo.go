@@ -0,0 +1,147 @@
+package rwlock
+
+import (
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tangledbytes/godc/internal/spin"
+)
+
+// inhibitFactor is how many times the time a writer spent revoking the
+// reader bias the bias stays off afterwards. This bounds the share of
+// time writers spend revoking it.
+const inhibitFactor = 9
+
+// slot is a reader count padded to a cache line of its own.
+type slot struct {
+	n atomic.Int64
+	_ [56]byte
+}
+
+// Bravo is a reader-writer lock for read mostly workloads. Readers
+// announce themselves in one of several slots instead of a single shared
+// count, so that concurrent readers rarely touch the same cache line. A
+// writer keeps new readers out and then waits for the sum of all slots to
+// drop to zero. Writers are preferred over readers.
+//
+// Go does not expose which P a goroutine runs on, nor can RUnlock learn
+// which slot the matching RLock used. Readers therefore pick a slot at
+// random both when entering and when leaving. The slots may go negative
+// but their sum stays the number of readers holding the lock, which is
+// all writers look at.
+//
+// While readers are biased towards the slots, a writer pays for checking
+// every one of them. So when a writer shows up the bias is revoked and
+// readers share the first slot until some time proportional to the cost
+// of the revocation has passed.
+//
+// This is adapted from Dice and Kogan, BRAVO - Biased Locking for
+// Reader-Writer Locks.
+type Bravo struct {
+	slots []slot
+
+	// bias tells readers to spread over the slots, inhibitUntil is when a
+	// reader may turn it back on in nanoseconds since the Unix epoch
+	bias         atomic.Bool
+	inhibitUntil atomic.Int64
+
+	// writer is set from the time a writer starts waiting for readers
+	// until it releases the lock
+	writer atomic.Bool
+
+	// writers serialises writers, mu and cond let readers wait for them
+	writers sync.Mutex
+	mu      sync.Mutex
+	cond    sync.Cond
+}
+
+func NewBravo() *Bravo {
+	l := &Bravo{
+		// more slots than CPUs cannot all be contended at once
+		slots: make([]slot, 1<<bits.Len(uint(runtime.NumCPU()-1))),
+	}
+	l.cond.L = &l.mu
+	l.bias.Store(true)
+
+	return l
+}
+
+func (l *Bravo) RLock() {
+	for {
+		biased := l.bias.Load()
+
+		s := l.slot(biased)
+		s.n.Add(1)
+
+		if !l.writer.Load() {
+			if !biased && time.Now().UnixNano() >= l.inhibitUntil.Load() {
+				l.bias.Store(true)
+			}
+			return
+		}
+
+		// a writer is waiting, let it go first
+		s.n.Add(-1)
+
+		l.mu.Lock()
+		for l.writer.Load() {
+			l.cond.Wait()
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (l *Bravo) RUnlock() {
+	l.slot(l.bias.Load()).n.Add(-1)
+}
+
+func (l *Bravo) Lock() {
+	l.writers.Lock()
+	l.writer.Store(true)
+
+	revoked := l.bias.Swap(false)
+	start := time.Now()
+
+	var s spin.Spinner
+	for l.readers() != 0 {
+		s.Spin()
+	}
+
+	if revoked {
+		l.inhibitUntil.Store(time.Now().Add(inhibitFactor * time.Since(start)).UnixNano())
+	}
+}
+
+func (l *Bravo) Unlock() {
+	l.mu.Lock()
+	l.writer.Store(false)
+	l.cond.Broadcast()
+	l.mu.Unlock()
+
+	l.writers.Unlock()
+}
+
+func (l *Bravo) slot(biased bool) *slot {
+	if !biased {
+		return &l.slots[0]
+	}
+
+	return &l.slots[rand.Uint32()&uint32(len(l.slots)-1)]
+}
+
+// readers returns the number of readers holding the lock, or possibly
+// more while readers come and go. Once a writer is waiting new readers
+// back out right away, so a sum of zero means that those holding the lock
+// are all gone.
+func (l *Bravo) readers() int64 {
+	sum := int64(0)
+	for i := range l.slots {
+		sum += l.slots[i].n.Load()
+	}
+
+	return sum
+}
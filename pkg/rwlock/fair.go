@@ -0,0 +1,74 @@
+package rwlock
+
+import "sync"
+
+// Fair is a reader-writer lock which is granted in the order it was
+// requested. Every request takes a ticket, a reader enters as soon as all
+// earlier requests were served, letting the next one in right away, while
+// a writer additionally waits for the readers before it to leave. Neither
+// side can starve, at the cost of readers queued behind a writer waiting
+// even when readers hold the lock.
+type Fair struct {
+	mu   sync.Mutex
+	cond sync.Cond
+
+	// next is the ticket of the next request and serving the ticket of
+	// the request allowed to enter next
+	next    uint64
+	serving uint64
+
+	readers int
+}
+
+func NewFair() *Fair {
+	l := &Fair{}
+	l.cond.L = &l.mu
+
+	return l
+}
+
+func (l *Fair) RLock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ticket := l.next
+	l.next++
+
+	for ticket != l.serving {
+		l.cond.Wait()
+	}
+
+	l.readers++
+	l.serving++
+	l.cond.Broadcast()
+}
+
+func (l *Fair) RUnlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+}
+
+func (l *Fair) Lock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ticket := l.next
+	l.next++
+
+	for ticket != l.serving || l.readers > 0 {
+		l.cond.Wait()
+	}
+}
+
+func (l *Fair) Unlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.serving++
+	l.cond.Broadcast()
+}
@@ -0,0 +1,27 @@
+// Package rwlock provides reader-writer locks with different policies for
+// choosing between waiting readers and writers.
+//
+// Every lock in this package implements RWLocker and, like sync.RWMutex,
+// may be unlocked by a goroutine other than the one that locked it. They
+// are adapted from The Art of Multiprocessor Programming, chapter 8,
+// unless noted otherwise.
+package rwlock
+
+import "sync"
+
+// RWLocker is a lock which can be held by a single writer or by any
+// number of readers at once.
+type RWLocker interface {
+	sync.Locker
+
+	RLock()
+	RUnlock()
+}
+
+var (
+	_ RWLocker = (*sync.RWMutex)(nil)
+	_ RWLocker = (*ReaderPref)(nil)
+	_ RWLocker = (*WriterPref)(nil)
+	_ RWLocker = (*Fair)(nil)
+	_ RWLocker = (*Bravo)(nil)
+)
@@ -0,0 +1,291 @@
+package rwlock
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func implementations() map[string]func() RWLocker {
+	return map[string]func() RWLocker{
+		"reader preference": func() RWLocker {
+			return NewReaderPref()
+		},
+		"writer preference": func() RWLocker {
+			return NewWriterPref()
+		},
+		"fair": func() RWLocker {
+			return NewFair()
+		},
+		"bravo": func() RWLocker {
+			return NewBravo()
+		},
+	}
+}
+
+func Test_RWLock_MutualExclusion(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newLock := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				l := newLock()
+
+				for i := 0; i < 1000; i++ {
+					l.Lock()
+					l.Unlock()
+
+					l.RLock()
+					l.RLock()
+					l.RUnlock()
+					l.RUnlock()
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		const goroutines, iterations = 16, 1000
+
+		for name, newLock := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				l := newLock()
+
+				// the race detector flags any access that is not ordered
+				// by the lock
+				count := 0
+				var readers, writers atomic.Int32
+
+				var wg sync.WaitGroup
+				for i := 0; i < goroutines; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+
+						for j := 0; j < iterations; j++ {
+							if (i+j)%4 == 0 {
+								l.Lock()
+								if w, r := writers.Add(1), readers.Load(); w != 1 || r != 0 {
+									t.Errorf("got %v writers and %v readers, want 1 and 0", w, r)
+								}
+								count++
+								writers.Add(-1)
+								l.Unlock()
+								continue
+							}
+
+							l.RLock()
+							readers.Add(1)
+							if w := writers.Load(); w != 0 {
+								t.Errorf("got %v writers, want 0", w)
+							}
+							if count > goroutines*iterations {
+								t.Errorf("got %v, want at most %v", count, goroutines*iterations)
+							}
+							readers.Add(-1)
+							l.RUnlock()
+						}
+					}(i)
+				}
+				wg.Wait()
+
+				if count != goroutines*iterations/4 {
+					t.Errorf("got %v, want %v", count, goroutines*iterations/4)
+				}
+			})
+		}
+	})
+}
+
+func Test_RWLock_SharedReaders(t *testing.T) {
+	for name, newLock := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			l := newLock()
+			l.RLock()
+
+			// another reader gets in while no writer is around
+			done := make(chan struct{})
+			go func() {
+				l.RLock()
+				l.RUnlock()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("reader blocked by another reader")
+			}
+
+			l.RUnlock()
+		})
+	}
+}
+
+// waitFor polls cond until it holds, failing the test if it never does.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blocked reports whether done stays open for a while.
+func blocked(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return false
+	case <-time.After(20 * time.Millisecond):
+		return true
+	}
+}
+
+func Test_ReaderPref_ReadersFirst(t *testing.T) {
+	l := NewReaderPref()
+	l.RLock()
+
+	writerDone := make(chan struct{})
+	go func() {
+		l.Lock()
+		l.Unlock()
+		close(writerDone)
+	}()
+
+	if !blocked(writerDone) {
+		t.Fatalf("writer got in while a reader held the lock")
+	}
+
+	// a waiting writer does not keep readers out
+	readerDone := make(chan struct{})
+	go func() {
+		l.RLock()
+		l.RUnlock()
+		close(readerDone)
+	}()
+	<-readerDone
+
+	l.RUnlock()
+	<-writerDone
+}
+
+func Test_WriterPref_WritersFirst(t *testing.T) {
+	l := NewWriterPref()
+	l.RLock()
+
+	writerDone := make(chan struct{})
+	go func() {
+		l.Lock()
+		l.Unlock()
+		close(writerDone)
+	}()
+
+	waitFor(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		return l.writer
+	})
+
+	// a waiting writer keeps new readers out
+	readerDone := make(chan struct{})
+	go func() {
+		l.RLock()
+		l.RUnlock()
+		close(readerDone)
+	}()
+
+	if !blocked(readerDone) {
+		t.Fatalf("reader got in while a writer was waiting")
+	}
+
+	l.RUnlock()
+	<-writerDone
+	<-readerDone
+}
+
+func Test_Bravo_WritersFirst(t *testing.T) {
+	l := NewBravo()
+	l.RLock()
+
+	writerDone := make(chan struct{})
+	go func() {
+		l.Lock()
+		l.Unlock()
+		close(writerDone)
+	}()
+
+	waitFor(t, l.writer.Load)
+
+	readerDone := make(chan struct{})
+	go func() {
+		l.RLock()
+		l.RUnlock()
+		close(readerDone)
+	}()
+
+	if !blocked(readerDone) {
+		t.Fatalf("reader got in while a writer was waiting")
+	}
+
+	l.RUnlock()
+	<-writerDone
+	<-readerDone
+
+	if l.bias.Load() && l.inhibitUntil.Load() == 0 {
+		t.Errorf("writer did not revoke the reader bias")
+	}
+}
+
+func Test_Fair_Order(t *testing.T) {
+	const goroutines = 8
+
+	l := NewFair()
+	l.Lock()
+
+	// queue readers and writers one at a time so that their tickets are
+	// in a known order
+	var order []int
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if i%2 == 0 {
+				l.Lock()
+				defer l.Unlock()
+			} else {
+				l.RLock()
+				defer l.RUnlock()
+			}
+
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		}(i)
+
+		waitFor(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			return l.next == uint64(i+2)
+		})
+	}
+
+	l.Unlock()
+	wg.Wait()
+
+	// every reader is followed by a writer so that it leaves before the
+	// next one enters
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("got order %v, want ascending", order)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package rwlock
+
+import "sync"
+
+// ReaderPref is a reader-writer lock which lets readers in whenever no
+// writer holds it. It has the least overhead but a steady stream of
+// readers starves writers indefinitely.
+type ReaderPref struct {
+	mu   sync.Mutex
+	cond sync.Cond
+
+	readers int
+	writer  bool
+}
+
+func NewReaderPref() *ReaderPref {
+	l := &ReaderPref{}
+	l.cond.L = &l.mu
+
+	return l
+}
+
+func (l *ReaderPref) RLock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.writer {
+		l.cond.Wait()
+	}
+	l.readers++
+}
+
+func (l *ReaderPref) RUnlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+}
+
+func (l *ReaderPref) Lock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.writer || l.readers > 0 {
+		l.cond.Wait()
+	}
+	l.writer = true
+}
+
+func (l *ReaderPref) Unlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.writer = false
+	l.cond.Broadcast()
+}
+
+// WriterPref is a reader-writer lock which stops letting readers in as
+// soon as a writer is waiting. The writer then waits only for the readers
+// already holding the lock, but a steady stream of writers starves
+// readers indefinitely.
+type WriterPref struct {
+	mu   sync.Mutex
+	cond sync.Cond
+
+	readers int
+
+	// writer is set as soon as a writer is waiting for the readers to
+	// leave, not only once it holds the lock
+	writer bool
+}
+
+func NewWriterPref() *WriterPref {
+	l := &WriterPref{}
+	l.cond.L = &l.mu
+
+	return l
+}
+
+func (l *WriterPref) RLock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.writer {
+		l.cond.Wait()
+	}
+	l.readers++
+}
+
+func (l *WriterPref) RUnlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+}
+
+func (l *WriterPref) Lock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// wait for the previous writer and then keep new readers out while
+	// the current ones leave
+	for l.writer {
+		l.cond.Wait()
+	}
+	l.writer = true
+
+	for l.readers > 0 {
+		l.cond.Wait()
+	}
+}
+
+func (l *WriterPref) Unlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.writer = false
+	l.cond.Broadcast()
+}
@@ -0,0 +1,213 @@
+// Package semaphore provides a weighted counting semaphore whose waiters
+// are served in FIFO order.
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/pkg/queue"
+)
+
+// ErrExceedsCapacity is returned when acquiring more permits than the
+// semaphore has, which could never succeed.
+var ErrExceedsCapacity = errors.New("semaphore: acquiring more permits than the capacity")
+
+const (
+	waiting int32 = iota
+	granted
+	cancelled
+)
+
+type waiter struct {
+	n     int64
+	state atomic.Int32
+	ready chan struct{}
+}
+
+// Semaphore hands out up to a fixed number of permits. Goroutines acquire
+// as many permits as they need and wait while there are not enough of
+// them available.
+//
+// Waiters are served strictly in FIFO order, a waiter which needs many
+// permits keeps smaller requests queued behind it waiting. While no one
+// waits permits are taken with a single CAS. Only waiting and handing
+// permits over to waiters goes through a mutex.
+//
+// Waiters which give up are not removed from the queue, they are marked as
+// cancelled and skipped once they reach its head.
+type Semaphore struct {
+	capacity  int64
+	available atomic.Int64
+
+	// waiters is the FIFO of waiters, live counts those not cancelled
+	waiters *queue.Queue[*waiter]
+	live    atomic.Int64
+
+	// mu serialises handing permits over to waiters
+	mu sync.Mutex
+}
+
+// New returns a semaphore with capacity permits, all of them available.
+func New(capacity int64) *Semaphore {
+	if capacity < 1 {
+		panic("semaphore: capacity must be positive")
+	}
+
+	s := &Semaphore{
+		capacity: capacity,
+		waiters:  queue.New[*waiter](),
+	}
+	s.available.Store(capacity)
+
+	return s
+}
+
+// Acquire takes n permits, waiting until they are available or ctx is
+// done. If ctx is done first its error is returned and no permits are
+// taken, unless they were handed over at the same time, in which case
+// they are kept and Acquire succeeds.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if err := s.check(n); err != nil {
+		return err
+	}
+
+	if s.live.Load() == 0 && s.take(n) {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	w := &waiter{n: n, ready: make(chan struct{})}
+
+	// announce the waiter before looking at the permits again, so that
+	// either we see those of a concurrent Release or it sees us
+	s.live.Add(1)
+	s.waiters.Push(w)
+	s.notify()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	if !w.state.CompareAndSwap(waiting, cancelled) {
+		// the permits were handed over anyway
+		return nil
+	}
+	s.live.Add(-1)
+
+	// we may have kept the waiters behind us from being served
+	s.notify()
+
+	return ctx.Err()
+}
+
+// TryAcquire takes n permits if they are available and no one is waiting
+// for permits, and reports whether it did.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	if s.check(n) != nil {
+		return false
+	}
+
+	return s.live.Load() == 0 && s.take(n)
+}
+
+// Release returns n permits to the semaphore. It panics, leaving the
+// semaphore untouched, if that makes more permits available than the
+// capacity.
+func (s *Semaphore) Release(n int64) {
+	if n < 0 {
+		panic("semaphore: negative permits")
+	}
+
+	for {
+		available := s.available.Load()
+		if available+n > s.capacity {
+			panic("semaphore: released more permits than were acquired")
+		}
+
+		if s.available.CompareAndSwap(available, available+n) {
+			break
+		}
+	}
+
+	if s.live.Load() > 0 {
+		s.notify()
+	}
+}
+
+// Available returns the number of permits which are not taken.
+func (s *Semaphore) Available() int64 {
+	return s.available.Load()
+}
+
+// Waiters returns the number of goroutines waiting for permits.
+func (s *Semaphore) Waiters() int64 {
+	return s.live.Load()
+}
+
+func (s *Semaphore) check(n int64) error {
+	if n < 0 {
+		panic("semaphore: negative permits")
+	}
+
+	if n > s.capacity {
+		return ErrExceedsCapacity
+	}
+
+	return nil
+}
+
+// take takes n permits if they are available.
+func (s *Semaphore) take(n int64) bool {
+	for {
+		available := s.available.Load()
+		if available < n {
+			return false
+		}
+
+		if s.available.CompareAndSwap(available, available-n) {
+			return true
+		}
+	}
+}
+
+// notify hands permits over to waiters from the head of the queue for as
+// long as there are enough of them.
+func (s *Semaphore) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		w, ok := s.waiters.Peek()
+		if !ok {
+			return
+		}
+
+		if w.state.Load() == cancelled {
+			s.waiters.Pop()
+			continue
+		}
+
+		if !s.take(w.n) {
+			return
+		}
+
+		if !w.state.CompareAndSwap(waiting, granted) {
+			// cancelled in the meantime, put the permits back
+			s.available.Add(w.n)
+			s.waiters.Pop()
+			continue
+		}
+
+		s.waiters.Pop()
+		s.live.Add(-1)
+		close(w.ready)
+	}
+}
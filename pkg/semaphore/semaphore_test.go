@@ -0,0 +1,213 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters polls until s has n waiters, failing the test if it
+// never does.
+func waitForWaiters(t *testing.T, s *Semaphore, n int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Waiters() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %v waiters, want %v", s.Waiters(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_Semaphore_TryAcquire(t *testing.T) {
+	s := New(3)
+
+	if !s.TryAcquire(2) {
+		t.Fatalf("could not acquire available permits")
+	}
+
+	if s.TryAcquire(2) {
+		t.Fatalf("acquired more permits than available")
+	}
+
+	if !s.TryAcquire(1) {
+		t.Fatalf("could not acquire available permits")
+	}
+
+	if got := s.Available(); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+
+	s.Release(3)
+
+	if got := s.Available(); got != 3 {
+		t.Errorf("got %v, want %v", got, 3)
+	}
+
+	if s.TryAcquire(4) {
+		t.Errorf("acquired more permits than the capacity")
+	}
+}
+
+func Test_Semaphore_ExceedsCapacity(t *testing.T) {
+	s := New(3)
+
+	if err := s.Acquire(context.Background(), 4); !errors.Is(err, ErrExceedsCapacity) {
+		t.Errorf("got %v, want %v", err, ErrExceedsCapacity)
+	}
+
+	if err := s.Acquire(context.Background(), 1); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("releasing unacquired permits did not panic")
+			}
+		}()
+
+		s.Release(2)
+	}()
+
+	// the failed release changed nothing, the permit is still there to
+	// give back
+	if got := s.Available(); got != 2 {
+		t.Errorf("got %v, want %v", got, 2)
+	}
+
+	s.Release(1)
+	if got := s.Available(); got != 3 {
+		t.Errorf("got %v, want %v", got, 3)
+	}
+}
+
+func Test_Semaphore_Cancel(t *testing.T) {
+	s := New(2)
+	if err := s.Acquire(context.Background(), 2); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := s.Acquire(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if got := s.Waiters(); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+
+	// a cancelled waiter at the head of the queue does not keep the ones
+	// behind it from being served
+	big, cancelBig := context.WithCancel(context.Background())
+	bigDone := make(chan error)
+	go func() {
+		bigDone <- s.Acquire(big, 2)
+	}()
+	waitForWaiters(t, s, 1)
+
+	smallDone := make(chan error)
+	go func() {
+		smallDone <- s.Acquire(context.Background(), 1)
+	}()
+	waitForWaiters(t, s, 2)
+
+	s.Release(1)
+	cancelBig()
+
+	if err := <-bigDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+
+	if err := <-smallDone; err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	s.Release(2)
+	if got := s.Available(); got != 2 {
+		t.Errorf("got %v, want %v", got, 2)
+	}
+}
+
+func Test_Semaphore_FIFO(t *testing.T) {
+	const goroutines = 8
+
+	s := New(goroutines)
+	if err := s.Acquire(context.Background(), goroutines); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	// queue waiters one at a time, each needing all the permits so that
+	// they are served one after the other
+	order := make(chan int, goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			if err := s.Acquire(context.Background(), goroutines); err != nil {
+				t.Errorf("got %v, want %v", err, nil)
+			}
+			order <- i
+			s.Release(goroutines)
+		}(i)
+
+		waitForWaiters(t, s, int64(i+1))
+	}
+
+	// no one barges in ahead of the waiters
+	if s.TryAcquire(0) {
+		t.Errorf("acquired permits while others were waiting")
+	}
+
+	s.Release(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		if got := <-order; got != i {
+			t.Errorf("got %v, want %v", got, i)
+		}
+	}
+}
+
+func Test_Semaphore_Bound(t *testing.T) {
+	const capacity, goroutines, iterations = 5, 16, 500
+
+	s := New(capacity)
+
+	var inUse atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			n := int64(i%capacity + 1)
+			for j := 0; j < iterations; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(j%50)*time.Microsecond)
+				err := s.Acquire(ctx, n)
+				cancel()
+				if err != nil {
+					continue
+				}
+
+				if got := inUse.Add(n); got > capacity {
+					t.Errorf("got %v permits in use, want at most %v", got, capacity)
+				}
+				inUse.Add(-n)
+				s.Release(n)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := s.Available(); got != capacity {
+		t.Errorf("got %v, want %v", got, capacity)
+	}
+
+	if got := s.Waiters(); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+}
@@ -0,0 +1,402 @@
+package skiplist
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+type lazyNode[K Ordered, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+	next  []atomic.Pointer[lazyNode[K, V]]
+
+	mu sync.Mutex
+
+	// marked is set once the node is logically deleted
+	marked atomic.Bool
+
+	// fullyLinked is set once the node is linked at every level, until
+	// then the key is not considered present
+	fullyLinked atomic.Bool
+}
+
+func newLazyNode[K Ordered, V any](key K, value *V, top int) *lazyNode[K, V] {
+	n := &lazyNode[K, V]{
+		key:  key,
+		next: make([]atomic.Pointer[lazyNode[K, V]], top+1),
+	}
+	n.value.Store(value)
+
+	return n
+}
+
+func (n *lazyNode[K, V]) present() bool {
+	return n.fullyLinked.Load() && !n.marked.Load()
+}
+
+// entry unpacks a node returned by floor or next, n may be nil.
+func (n *lazyNode[K, V]) entry() (K, V, bool) {
+	if n == nil {
+		var key K
+		var def V
+
+		return key, def, false
+	}
+
+	return n.key, *n.value.Load(), true
+}
+
+// Lazy is a lock-based skiplist ordered map.
+//
+// Writers lock the predecessors of the key at every level it spans and
+// validate them before changing the list, a node is logically deleted by
+// marking it before it is unlinked. Lookups take no locks and are
+// wait-free.
+//
+// Unlike LockFree, nodes carry plain pointers so that an insert allocates
+// the node and a single slice for its tower rather than a reference per
+// level.
+//
+// This is adapted from The Art of Multiprocessor Programming, 14.3.
+type Lazy[K Ordered, V any] struct {
+	head  *lazyNode[K, V]
+	count atomic.Int64
+}
+
+func NewLazy[K Ordered, V any]() *Lazy[K, V] {
+	var key K
+
+	return &Lazy[K, V]{
+		head: newLazyNode[K, V](key, nil, maxLevel-1),
+	}
+}
+
+// Get returns the value stored in the map for a key and reports whether
+// it was present.
+func (s *Lazy[K, V]) Get(key K) (V, bool) {
+	var def V
+	var preds, succs [maxLevel]*lazyNode[K, V]
+
+	lFound := s.find(key, &preds, &succs)
+	if lFound < 0 {
+		return def, false
+	}
+
+	n := succs[lFound]
+	v := n.value.Load()
+	if !n.present() {
+		return def, false
+	}
+
+	return *v, true
+}
+
+// Put sets the value for a key. It returns the previous value and reports
+// whether there was one.
+func (s *Lazy[K, V]) Put(key K, value V) (V, bool) {
+	var def V
+	var preds, succs [maxLevel]*lazyNode[K, V]
+
+	top := randomLevel()
+	for {
+		if lFound := s.find(key, &preds, &succs); lFound >= 0 {
+			n := succs[lFound]
+			if n.marked.Load() {
+				// n is being deleted, wait for it to be unlinked
+				runtime.Gosched()
+				continue
+			}
+
+			for !n.fullyLinked.Load() {
+				runtime.Gosched()
+			}
+
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				continue
+			}
+
+			old := n.value.Swap(&value)
+			n.mu.Unlock()
+
+			return *old, true
+		}
+
+		highest, valid := s.lockPreds(&preds, top, func(level int, pred *lazyNode[K, V]) bool {
+			succ := succs[level]
+			return (succ == nil || !succ.marked.Load()) && pred.next[level].Load() == succ
+		})
+		if !valid {
+			unlockPreds(&preds, highest)
+			continue
+		}
+
+		n := newLazyNode(key, &value, top)
+		for level := 0; level <= top; level++ {
+			n.next[level].Store(succs[level])
+		}
+
+		for level := 0; level <= top; level++ {
+			preds[level].next[level].Store(n)
+		}
+
+		n.fullyLinked.Store(true)
+		s.count.Add(1)
+
+		unlockPreds(&preds, highest)
+		return def, false
+	}
+}
+
+// Delete deletes the value for a key. It returns the deleted value and
+// reports whether the key was present.
+func (s *Lazy[K, V]) Delete(key K) (V, bool) {
+	var def V
+	var preds, succs [maxLevel]*lazyNode[K, V]
+	var victim *lazyNode[K, V]
+
+	for {
+		lFound := s.find(key, &preds, &succs)
+
+		if victim == nil {
+			// only a node which is fully linked and found at its top level
+			// is safe to delete, otherwise it is still being added or
+			// someone else is deleting it
+			if lFound < 0 {
+				return def, false
+			}
+
+			n := succs[lFound]
+			if !n.fullyLinked.Load() || len(n.next)-1 != lFound || n.marked.Load() {
+				return def, false
+			}
+
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				return def, false
+			}
+
+			// the key is deleted from here on, victim stays locked until
+			// it is unlinked
+			n.marked.Store(true)
+			victim = n
+		}
+
+		top := len(victim.next) - 1
+		highest, valid := s.lockPreds(&preds, top, func(level int, pred *lazyNode[K, V]) bool {
+			return pred.next[level].Load() == victim
+		})
+		if !valid {
+			unlockPreds(&preds, highest)
+			continue
+		}
+
+		for level := top; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		s.count.Add(-1)
+
+		victim.mu.Unlock()
+		unlockPreds(&preds, highest)
+
+		return *victim.value.Load(), true
+	}
+}
+
+// Contains reports whether the key is present in the map.
+func (s *Lazy[K, V]) Contains(key K) bool {
+	var preds, succs [maxLevel]*lazyNode[K, V]
+
+	lFound := s.find(key, &preds, &succs)
+	return lFound >= 0 && succs[lFound].present()
+}
+
+// Floor returns the greatest key less than or equal to the given key
+// along with its value.
+func (s *Lazy[K, V]) Floor(key K) (K, V, bool) {
+	return s.floor(key, true).entry()
+}
+
+// Ceiling returns the least key greater than or equal to the given key
+// along with its value.
+func (s *Lazy[K, V]) Ceiling(key K) (K, V, bool) {
+	return s.next(s.ceil(key)).entry()
+}
+
+// Ascend calls f for each key and value in ascending order. If f returns
+// false, the iteration stops.
+func (s *Lazy[K, V]) Ascend(f func(key K, value V) bool) {
+	for n := s.next(s.head.next[0].Load()); n != nil; n = s.next(n.next[0].Load()) {
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
+
+// AscendRange calls f for each key in [greaterOrEqual, lessThan) in
+// ascending order. If f returns false, the iteration stops.
+func (s *Lazy[K, V]) AscendRange(greaterOrEqual, lessThan K, f func(key K, value V) bool) {
+	for n := s.next(s.ceil(greaterOrEqual)); n != nil && n.key < lessThan; n = s.next(n.next[0].Load()) {
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
+
+// Descend calls f for each key and value in descending order. If f
+// returns false, the iteration stops.
+//
+// There are no backward links so every step is a search from the head.
+func (s *Lazy[K, V]) Descend(f func(key K, value V) bool) {
+	for n := s.last(); n != nil; n = s.floor(n.key, false) {
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
+
+// DescendRange calls f for each key in (greaterThan, lessOrEqual] in
+// descending order. If f returns false, the iteration stops.
+func (s *Lazy[K, V]) DescendRange(lessOrEqual, greaterThan K, f func(key K, value V) bool) {
+	for n := s.floor(lessOrEqual, true); n != nil && n.key > greaterThan; n = s.floor(n.key, false) {
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
+
+// Len returns the number of items in the map.
+func (s *Lazy[K, V]) Len() int {
+	return int(s.count.Load())
+}
+
+// find fills preds and succs with the neighbours of key at every level
+// and returns the highest level at which the key was found, or -1.
+func (s *Lazy[K, V]) find(key K, preds, succs *[maxLevel]*lazyNode[K, V]) int {
+	lFound := -1
+
+	pred := s.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && curr.key < key {
+			pred, curr = curr, curr.next[level].Load()
+		}
+
+		if lFound < 0 && curr != nil && curr.key == key {
+			lFound = level
+		}
+
+		preds[level], succs[level] = pred, curr
+	}
+
+	return lFound
+}
+
+// lockPreds locks the predecessors at levels [0, top] from the bottom up
+// and checks that each is unmarked and passes valid. It returns the
+// highest level locked, which must be passed to unlockPreds, and whether
+// every predecessor was valid.
+func (s *Lazy[K, V]) lockPreds(preds *[maxLevel]*lazyNode[K, V], top int, valid func(level int, pred *lazyNode[K, V]) bool) (int, bool) {
+	var prev *lazyNode[K, V]
+
+	for level := 0; level <= top; level++ {
+		pred := preds[level]
+
+		// the same node is often the predecessor at several consecutive
+		// levels and the locks are not reentrant
+		if pred != prev {
+			pred.mu.Lock()
+			prev = pred
+		}
+
+		if pred.marked.Load() || !valid(level, pred) {
+			return level, false
+		}
+	}
+
+	return top, true
+}
+
+func unlockPreds[K Ordered, V any](preds *[maxLevel]*lazyNode[K, V], highest int) {
+	var prev *lazyNode[K, V]
+
+	for level := 0; level <= highest; level++ {
+		if pred := preds[level]; pred != prev {
+			pred.mu.Unlock()
+			prev = pred
+		}
+	}
+}
+
+// ceil returns the first bottom level node whose key is greater than or
+// equal to key. The node may not be present.
+func (s *Lazy[K, V]) ceil(key K) *lazyNode[K, V] {
+	var curr *lazyNode[K, V]
+
+	pred := s.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr = pred.next[level].Load()
+		for curr != nil && curr.key < key {
+			pred, curr = curr, curr.next[level].Load()
+		}
+	}
+
+	return curr
+}
+
+// floor returns the last present node whose key is less than key, or
+// equal to it if inclusive.
+func (s *Lazy[K, V]) floor(key K, inclusive bool) *lazyNode[K, V] {
+	for {
+		pred := s.head
+		for level := maxLevel - 1; level >= 0; level-- {
+			curr := pred.next[level].Load()
+			for curr != nil && (curr.key < key || (inclusive && curr.key == key)) {
+				pred, curr = curr, curr.next[level].Load()
+			}
+		}
+
+		if pred == s.head {
+			return nil
+		}
+
+		if pred.present() {
+			return pred
+		}
+
+		// pred is being added or deleted, look before it
+		key, inclusive = pred.key, false
+	}
+}
+
+// last returns the last present node.
+func (s *Lazy[K, V]) last() *lazyNode[K, V] {
+	pred := s.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		for curr := pred.next[level].Load(); curr != nil; curr = curr.next[level].Load() {
+			pred = curr
+		}
+	}
+
+	if pred == s.head {
+		return nil
+	}
+
+	if pred.present() {
+		return pred
+	}
+
+	return s.floor(pred.key, false)
+}
+
+// next returns the first present node at the bottom level starting from n.
+func (s *Lazy[K, V]) next(n *lazyNode[K, V]) *lazyNode[K, V] {
+	for n != nil && !n.present() {
+		n = n.next[0].Load()
+	}
+
+	return n
+}
@@ -0,0 +1,397 @@
+package skiplist
+
+import (
+	"sync/atomic"
+
+	"github.com/tangledbytes/godc/internal/sched"
+	amr "github.com/tangledbytes/godc/pkg/atomicmarkablereference"
+)
+
+type lfNode[K Ordered, V any] struct {
+	key K
+
+	// value is nil once the node is logically deleted
+	value atomic.Pointer[V]
+
+	// next holds the successor at each level of the node, the mark is set
+	// when the node is being removed from that level
+	next []*amr.AtomicMarkableReference[lfNode[K, V]]
+}
+
+func newLFNode[K Ordered, V any](key K, value *V, top int) *lfNode[K, V] {
+	n := &lfNode[K, V]{
+		key:  key,
+		next: make([]*amr.AtomicMarkableReference[lfNode[K, V]], top+1),
+	}
+	n.value.Store(value)
+
+	for i := range n.next {
+		n.next[i] = amr.New[lfNode[K, V]](nil, false)
+	}
+
+	return n
+}
+
+// mark marks every level of n from the top down so that the bottom level,
+// which decides membership in the list, is marked last.
+func (n *lfNode[K, V]) mark() {
+	for level := len(n.next) - 1; level >= 0; level-- {
+		succ, marked := n.next[level].Get()
+		for !marked {
+			sched.Yield("skiplist: mark loaded successor")
+			n.next[level].CompareAndSet(succ, succ, false, true)
+			succ, marked = n.next[level].Get()
+		}
+	}
+}
+
+// LockFree is a lock-free skiplist based ordered map.
+//
+// Every level of the skiplist is a Harris-Michael list whose next pointers
+// are AtomicMarkableReferences, a node is removed from a level by first
+// marking its next pointer at that level and then unlinking it. Lookups
+// never write to the list and are wait-free.
+//
+// A key is deleted once its value is swapped to nil, which lets updates to
+// the value of a present key race with deletions without losing either.
+//
+// This is adapted from The Art of Multiprocessor Programming, 14.4.
+type LockFree[K Ordered, V any] struct {
+	head  *lfNode[K, V]
+	count atomic.Int64
+}
+
+func NewLockFree[K Ordered, V any]() *LockFree[K, V] {
+	var key K
+
+	return &LockFree[K, V]{
+		head: newLFNode[K, V](key, nil, maxLevel-1),
+	}
+}
+
+// Get returns the value stored in the map for a key and reports whether
+// it was present.
+func (s *LockFree[K, V]) Get(key K) (V, bool) {
+	var def V
+
+	n := s.ceil(key, true)
+	if n == nil || n.key != key {
+		return def, false
+	}
+
+	v := n.value.Load()
+	if v == nil {
+		return def, false
+	}
+
+	return *v, true
+}
+
+// Put sets the value for a key. It returns the previous value and reports
+// whether there was one.
+func (s *LockFree[K, V]) Put(key K, value V) (V, bool) {
+	var def V
+	var preds, succs [maxLevel]*lfNode[K, V]
+
+	top := randomLevel()
+	n := newLFNode(key, &value, top)
+
+	for {
+		if s.find(key, &preds, &succs) {
+			curr := succs[0]
+			if old := curr.value.Load(); old != nil {
+				sched.Yield("skiplist: put loaded value")
+				if curr.value.CompareAndSwap(old, &value) {
+					return *old, true
+				}
+				continue
+			}
+
+			// curr is being deleted, help unlink it before retrying
+			curr.mark()
+			continue
+		}
+
+		for level := 0; level <= top; level++ {
+			n.next[level].Set(succs[level], false)
+		}
+
+		// linking the bottom level adds the key to the map
+		sched.Yield("skiplist: put found position")
+		if !preds[0].next[0].CompareAndSet(succs[0], n, false, false) {
+			continue
+		}
+		s.count.Add(1)
+
+		s.link(n, &preds, &succs)
+		return def, false
+	}
+}
+
+// Delete deletes the value for a key. It returns the deleted value and
+// reports whether the key was present.
+func (s *LockFree[K, V]) Delete(key K) (V, bool) {
+	var def V
+	var preds, succs [maxLevel]*lfNode[K, V]
+
+	if !s.find(key, &preds, &succs) {
+		return def, false
+	}
+
+	n := succs[0]
+	for {
+		old := n.value.Load()
+		if old == nil {
+			// someone else deleted it
+			return def, false
+		}
+		sched.Yield("skiplist: delete loaded value")
+
+		if n.value.CompareAndSwap(old, nil) {
+			n.mark()
+			s.count.Add(-1)
+
+			// unlink n from every level
+			s.find(key, &preds, &succs)
+			return *old, true
+		}
+	}
+}
+
+// Contains reports whether the key is present in the map.
+func (s *LockFree[K, V]) Contains(key K) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+// Floor returns the greatest key less than or equal to the given key
+// along with its value.
+func (s *LockFree[K, V]) Floor(key K) (K, V, bool) {
+	n, v := s.floor(key, true)
+	return n.entry(v)
+}
+
+// Ceiling returns the least key greater than or equal to the given key
+// along with its value.
+func (s *LockFree[K, V]) Ceiling(key K) (K, V, bool) {
+	n, v := s.next(s.ceil(key, true))
+	return n.entry(v)
+}
+
+// Ascend calls f for each key and value in ascending order. If f returns
+// false, the iteration stops.
+func (s *LockFree[K, V]) Ascend(f func(key K, value V) bool) {
+	for n, v := s.next(s.head.next[0].GetReference()); n != nil; n, v = s.next(n.next[0].GetReference()) {
+		if !f(n.key, *v) {
+			return
+		}
+	}
+}
+
+// AscendRange calls f for each key in [greaterOrEqual, lessThan) in
+// ascending order. If f returns false, the iteration stops.
+func (s *LockFree[K, V]) AscendRange(greaterOrEqual, lessThan K, f func(key K, value V) bool) {
+	for n, v := s.next(s.ceil(greaterOrEqual, true)); n != nil && n.key < lessThan; n, v = s.next(n.next[0].GetReference()) {
+		if !f(n.key, *v) {
+			return
+		}
+	}
+}
+
+// Descend calls f for each key and value in descending order. If f
+// returns false, the iteration stops.
+//
+// There are no backward links so every step is a search from the head.
+func (s *LockFree[K, V]) Descend(f func(key K, value V) bool) {
+	for n, v := s.last(); n != nil; n, v = s.floor(n.key, false) {
+		if !f(n.key, *v) {
+			return
+		}
+	}
+}
+
+// DescendRange calls f for each key in (greaterThan, lessOrEqual] in
+// descending order. If f returns false, the iteration stops.
+func (s *LockFree[K, V]) DescendRange(lessOrEqual, greaterThan K, f func(key K, value V) bool) {
+	for n, v := s.floor(lessOrEqual, true); n != nil && n.key > greaterThan; n, v = s.floor(n.key, false) {
+		if !f(n.key, *v) {
+			return
+		}
+	}
+}
+
+// Len returns the number of items in the map.
+func (s *LockFree[K, V]) Len() int {
+	return int(s.count.Load())
+}
+
+// find fills preds and succs with the unmarked neighbours of key at every
+// level, unlinking the marked nodes it runs into, and reports whether
+// succs[0] holds the key.
+func (s *LockFree[K, V]) find(key K, preds, succs *[maxLevel]*lfNode[K, V]) bool {
+retry:
+	for {
+		pred := s.head
+		for level := maxLevel - 1; level >= 0; level-- {
+			curr := pred.next[level].GetReference()
+			for curr != nil {
+				succ, marked := curr.next[level].Get()
+				if marked {
+					// curr is being deleted, snip it out
+					sched.Yield("skiplist: find snipping node")
+					if !pred.next[level].CompareAndSet(curr, succ, false, false) {
+						continue retry
+					}
+
+					curr = succ
+					continue
+				}
+
+				if curr.key >= key {
+					break
+				}
+
+				pred, curr = curr, succ
+			}
+
+			preds[level], succs[level] = pred, curr
+		}
+
+		return succs[0] != nil && succs[0].key == key
+	}
+}
+
+// link links n, which is already in the bottom level, into the levels
+// above it. It gives up as soon as n is being deleted.
+func (s *LockFree[K, V]) link(n *lfNode[K, V], preds, succs *[maxLevel]*lfNode[K, V]) {
+	for level := 1; level < len(n.next); level++ {
+		for {
+			next, marked := n.next[level].Get()
+			if marked {
+				return
+			}
+
+			if next != succs[level] && !n.next[level].CompareAndSet(next, succs[level], false, false) {
+				continue
+			}
+
+			sched.Yield("skiplist: link found position")
+			if preds[level].next[level].CompareAndSet(succs[level], n, false, false) {
+				break
+			}
+
+			s.find(n.key, preds, succs)
+		}
+	}
+}
+
+// ceil returns the first unmarked bottom level node whose key is greater
+// than key, or equal to it if inclusive. The node may have been deleted.
+func (s *LockFree[K, V]) ceil(key K, inclusive bool) *lfNode[K, V] {
+	var curr *lfNode[K, V]
+
+	pred := s.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr = pred.next[level].GetReference()
+		for curr != nil {
+			succ, marked := curr.next[level].Get()
+			if marked {
+				curr = succ
+				continue
+			}
+
+			if curr.key > key || (inclusive && curr.key == key) {
+				break
+			}
+
+			pred, curr = curr, succ
+		}
+	}
+
+	return curr
+}
+
+// floor returns the last present node whose key is less than key, or
+// equal to it if inclusive, along with its value.
+func (s *LockFree[K, V]) floor(key K, inclusive bool) (*lfNode[K, V], *V) {
+	for {
+		pred := s.head
+		for level := maxLevel - 1; level >= 0; level-- {
+			curr := pred.next[level].GetReference()
+			for curr != nil {
+				succ, marked := curr.next[level].Get()
+				if marked {
+					curr = succ
+					continue
+				}
+
+				if curr.key > key || (!inclusive && curr.key == key) {
+					break
+				}
+
+				pred, curr = curr, succ
+			}
+		}
+
+		if pred == s.head {
+			return nil, nil
+		}
+
+		if v := pred.value.Load(); v != nil {
+			return pred, v
+		}
+
+		// pred got deleted in the meantime, look before it
+		key, inclusive = pred.key, false
+	}
+}
+
+// last returns the last present node along with its value.
+func (s *LockFree[K, V]) last() (*lfNode[K, V], *V) {
+	pred := s.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].GetReference()
+		for curr != nil {
+			succ, marked := curr.next[level].Get()
+			if !marked {
+				pred = curr
+			}
+
+			curr = succ
+		}
+	}
+
+	if pred == s.head {
+		return nil, nil
+	}
+
+	if v := pred.value.Load(); v != nil {
+		return pred, v
+	}
+
+	return s.floor(pred.key, false)
+}
+
+// next returns the first present node at the bottom level starting from
+// n along with its value.
+func (s *LockFree[K, V]) next(n *lfNode[K, V]) (*lfNode[K, V], *V) {
+	for ; n != nil; n = n.next[0].GetReference() {
+		if v := n.value.Load(); v != nil {
+			return n, v
+		}
+	}
+
+	return nil, nil
+}
+
+// entry unpacks a node returned by floor or next, n may be nil.
+func (n *lfNode[K, V]) entry(v *V) (K, V, bool) {
+	if n == nil {
+		var key K
+		var def V
+
+		return key, def, false
+	}
+
+	return n.key, *v, true
+}
@@ -0,0 +1,79 @@
+// Package skiplist provides concurrent ordered maps built on skiplists.
+//
+// All the maps in this package implement Map whose API loosely follows
+// Java's ConcurrentSkipListMap.
+package skiplist
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// maxLevel is the number of levels of a skiplist, enough for 2^32 keys.
+const maxLevel = 32
+
+// Ordered is a constraint that permits any type which supports the <
+// operator.
+//
+// Floating point NaNs are not ordered and must not be used as keys.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Map is a concurrent map from keys of type K to values of type V which
+// keeps its keys sorted.
+//
+// Iterations are weakly consistent, they reflect the state of the map at
+// some point at or since their creation and never visit a key twice.
+type Map[K Ordered, V any] interface {
+	// Get returns the value stored in the map for a key and reports
+	// whether it was present.
+	Get(key K) (value V, ok bool)
+
+	// Put sets the value for a key. It returns the previous value and
+	// reports whether there was one.
+	Put(key K, value V) (previous V, replaced bool)
+
+	// Delete deletes the value for a key. It returns the deleted value and
+	// reports whether the key was present.
+	Delete(key K) (value V, ok bool)
+
+	// Contains reports whether the key is present in the map.
+	Contains(key K) bool
+
+	// Floor returns the greatest key less than or equal to the given key
+	// along with its value.
+	Floor(key K) (floor K, value V, ok bool)
+
+	// Ceiling returns the least key greater than or equal to the given key
+	// along with its value.
+	Ceiling(key K) (ceiling K, value V, ok bool)
+
+	// Ascend calls f for each key and value in ascending order. If f
+	// returns false, the iteration stops.
+	Ascend(f func(key K, value V) bool)
+
+	// AscendRange calls f for each key in [greaterOrEqual, lessThan) in
+	// ascending order. If f returns false, the iteration stops.
+	AscendRange(greaterOrEqual, lessThan K, f func(key K, value V) bool)
+
+	// Descend calls f for each key and value in descending order. If f
+	// returns false, the iteration stops.
+	Descend(f func(key K, value V) bool)
+
+	// DescendRange calls f for each key in (greaterThan, lessOrEqual] in
+	// descending order. If f returns false, the iteration stops.
+	DescendRange(lessOrEqual, greaterThan K, f func(key K, value V) bool)
+
+	// Len returns the number of items in the map.
+	Len() int
+}
+
+// randomLevel returns the top level of a new node, level l is picked with
+// probability 1/2^(l+1).
+func randomLevel() int {
+	return bits.TrailingZeros64(rand.Uint64() | 1<<(maxLevel-1))
+}
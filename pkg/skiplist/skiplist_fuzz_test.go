@@ -0,0 +1,192 @@
+package skiplist_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+)
+
+const (
+	fuzzGoroutines = 4
+
+	// fuzzMaxOps keeps the histories small enough to check quickly
+	fuzzMaxOps = 64
+
+	// fuzzKeys is the number of distinct keys, few enough for the
+	// operations to keep running into each other
+	fuzzKeys = 8
+)
+
+type fuzzOpKind byte
+
+const (
+	fuzzPut fuzzOpKind = iota
+	fuzzDelete
+	fuzzContains
+	fuzzGet
+)
+
+type fuzzOp struct {
+	goroutine int
+	kind      fuzzOpKind
+	key       int
+	value     int
+}
+
+// decodeFuzzProgram decodes the fuzzer's input into the name of the map
+// and a program. The first byte picks the map, then every pair of bytes is
+// an operation: the low two bits of the first byte pick the operation, the
+// next two the goroutine running it, while the low three bits of the
+// second byte are the key and the rest the put value.
+func decodeFuzzProgram(data []byte) (string, []fuzzOp) {
+	var names []string
+	for name := range implementations() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	name := names[0]
+	if len(data) > 0 {
+		name = names[int(data[0])%len(names)]
+		data = data[1:]
+	}
+
+	var ops []fuzzOp
+	for i := 0; i+1 < len(data) && len(ops) < fuzzMaxOps; i += 2 {
+		ops = append(ops, fuzzOp{
+			goroutine: int(data[i]>>2) % fuzzGoroutines,
+			kind:      fuzzOpKind(data[i] & 3),
+			key:       int(data[i+1]) % fuzzKeys,
+			value:     int(data[i+1]) / fuzzKeys,
+		})
+	}
+
+	return name, ops
+}
+
+func Fuzz_Map(f *testing.F) {
+	f.Add([]byte{0, 0, 8, 2, 0, 1, 0, 2, 0})
+	f.Add([]byte{1, 0, 9, 4, 17, 1, 25, 14, 1, 3, 1, 6, 1})
+	f.Add([]byte{1, 0, 0, 5, 1, 10, 2, 15, 3, 0, 8, 6, 9, 11, 10, 12, 11, 1, 0, 7, 1, 2, 2, 4, 3})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		name, ops := decodeFuzzProgram(data)
+		newMap := implementations()[name]
+
+		t.Run("sequential", func(t *testing.T) {
+			m := newMap()
+
+			model := map[int]int{}
+			for _, op := range ops {
+				want, present := model[op.key]
+
+				switch op.kind {
+				case fuzzPut:
+					previous, replaced := m.Put(op.key, op.value)
+					if replaced != present || previous != want {
+						t.Fatalf("%v: got %v %v, want %v %v", name, previous, replaced, want, present)
+					}
+					model[op.key] = op.value
+				case fuzzDelete:
+					value, ok := m.Delete(op.key)
+					if ok != present || value != want {
+						t.Fatalf("%v: got %v %v, want %v %v", name, value, ok, want, present)
+					}
+					delete(model, op.key)
+				case fuzzContains:
+					if got := m.Contains(op.key); got != present {
+						t.Fatalf("%v: got %v, want %v", name, got, present)
+					}
+				case fuzzGet:
+					value, ok := m.Get(op.key)
+					if ok != present || value != want {
+						t.Fatalf("%v: got %v %v, want %v %v", name, value, ok, want, present)
+					}
+				}
+			}
+
+			var keys []int
+			for key := range model {
+				keys = append(keys, key)
+			}
+			sort.Ints(keys)
+
+			var got []int
+			m.Ascend(func(key, value int) bool {
+				if value != model[key] {
+					t.Fatalf("%v: got %v, want %v", name, value, model[key])
+				}
+
+				got = append(got, key)
+				return true
+			})
+
+			if !equal(got, keys) {
+				t.Fatalf("%v: got %v, want %v", name, got, keys)
+			}
+		})
+
+		t.Run("concurrent", func(t *testing.T) {
+			type input = lincheck.SetInput[int]
+
+			m := newMap()
+			r := lincheck.NewRecorder[input, bool]()
+
+			programs := make([][]fuzzOp, fuzzGoroutines)
+			for _, op := range ops {
+				programs[op.goroutine] = append(programs[op.goroutine], op)
+			}
+
+			// the keys of the map are checked against the model of a set
+			var wg sync.WaitGroup
+			for g, program := range programs {
+				wg.Add(1)
+				go func(g int, program []fuzzOp) {
+					defer wg.Done()
+
+					for _, op := range program {
+						key, value := op.key, op.value
+
+						switch op.kind {
+						case fuzzPut:
+							r.Record(g, input{Op: lincheck.Add, Key: key}, func() bool {
+								_, replaced := m.Put(key, value)
+								return !replaced
+							})
+						case fuzzDelete:
+							r.Record(g, input{Op: lincheck.Remove, Key: key}, func() bool {
+								_, ok := m.Delete(key)
+								return ok
+							})
+						case fuzzContains:
+							r.Record(g, input{Op: lincheck.Contains, Key: key}, func() bool {
+								return m.Contains(key)
+							})
+						case fuzzGet:
+							r.Record(g, input{Op: lincheck.Contains, Key: key}, func() bool {
+								_, ok := m.Get(key)
+								return ok
+							})
+						}
+					}
+				}(g, program)
+			}
+			wg.Wait()
+
+			// look every key up once quiescent so that lost or resurrected
+			// keys show up in the history
+			for key := 0; key < fuzzKeys; key++ {
+				key := key
+				r.Record(fuzzGoroutines, input{Op: lincheck.Contains, Key: key}, func() bool {
+					return m.Contains(key)
+				})
+			}
+
+			if !lincheck.Check(lincheck.SetModel[int](), r.History()) {
+				t.Errorf("%v: history is not linearizable: %v", name, r.History())
+			}
+		})
+	})
+}
@@ -0,0 +1,474 @@
+package skiplist_test
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tangledbytes/godc/internal/lincheck"
+	"github.com/tangledbytes/godc/internal/util"
+	"github.com/tangledbytes/godc/pkg/skiplist"
+)
+
+func implementations() map[string]func() skiplist.Map[int, int] {
+	return map[string]func() skiplist.Map[int, int]{
+		"lock free": func() skiplist.Map[int, int] {
+			return skiplist.NewLockFree[int, int]()
+		},
+		"lazy": func() skiplist.Map[int, int] {
+			return skiplist.NewLazy[int, int]()
+		},
+	}
+}
+
+func Test_Map_Put(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		type test struct {
+			name string
+			data []int
+			want []int
+		}
+
+		tests := []test{
+			{
+				name: "empty",
+				data: []int{},
+				want: []int{},
+			},
+			{
+				name: "single element",
+				data: []int{1},
+				want: []int{1},
+			},
+			{
+				name: "overwrite",
+				data: []int{3, 1, 3, 2, 1},
+				want: []int{1, 2, 3},
+			},
+			{
+				name: "multiple elements",
+				data: util.GenerateRandomIntSeries(1, 1000),
+				want: util.GenerateIntSeries(1, 1000),
+			},
+		}
+
+		for name, newMap := range implementations() {
+			for _, tt := range tests {
+				t.Run(name+" - "+tt.name, func(t *testing.T) {
+					m := newMap()
+
+					for _, data := range tt.data {
+						prev, replaced := m.Get(data)
+						if got, ok := m.Put(data, data*10); got != prev || ok != replaced {
+							t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{prev, replaced})
+						}
+					}
+
+					for _, data := range tt.want {
+						if got, ok := m.Get(data); got != data*10 || !ok {
+							t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{data * 10, true})
+						}
+					}
+
+					if got := ascend(m); !equal(got, tt.want) {
+						t.Errorf("got %v, want %v", got, tt.want)
+					}
+
+					if got := m.Len(); got != len(tt.want) {
+						t.Errorf("got %v, want %v", got, len(tt.want))
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+				data := util.GenerateRandomIntSeries(1, 1000)
+
+				var wg sync.WaitGroup
+				for _, d := range data {
+					wg.Add(1)
+					go func(d int) {
+						m.Put(d, d)
+						wg.Done()
+					}(d)
+				}
+				wg.Wait()
+
+				want := util.GenerateIntSeries(1, 1000)
+				if got := ascend(m); !equal(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func Test_Map_Delete(t *testing.T) {
+	t.Run("single threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				for _, d := range util.GenerateIntSeries(1, 1000) {
+					m.Put(d, d)
+				}
+
+				for _, d := range util.GenerateRandomIntSeries(1, 800) {
+					if got, ok := m.Delete(d); got != d || !ok {
+						t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{d, true})
+					}
+
+					if got, ok := m.Delete(d); ok {
+						t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{0, false})
+					}
+
+					if m.Contains(d) {
+						t.Errorf("deleted key %v is still present", d)
+					}
+				}
+
+				want := util.GenerateIntSeries(801, 1000)
+				if got := ascend(m); !equal(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+
+				if got := m.Len(); got != len(want) {
+					t.Errorf("got %v, want %v", got, len(want))
+				}
+			})
+		}
+	})
+
+	t.Run("multi threaded", func(t *testing.T) {
+		for name, newMap := range implementations() {
+			t.Run(name, func(t *testing.T) {
+				m := newMap()
+
+				for _, d := range util.GenerateIntSeries(1, 1000) {
+					m.Put(d, d)
+				}
+
+				// racing deletes of the same key, only one may win
+				wins := make([]atomic.Int32, 501)
+				del := func(d int) {
+					if _, ok := m.Delete(d); ok {
+						wins[d].Add(1)
+					}
+				}
+
+				var wg sync.WaitGroup
+				for _, d := range util.GenerateIntSeries(1, 500) {
+					wg.Add(3)
+					go func(d int) {
+						del(d)
+						wg.Done()
+					}(d)
+
+					go func(d int) {
+						del(d)
+						wg.Done()
+					}(d)
+
+					go func(d int) {
+						m.Put(d+1000, d+1000)
+						wg.Done()
+					}(d)
+				}
+				wg.Wait()
+
+				for _, d := range util.GenerateIntSeries(1, 500) {
+					if got := wins[d].Load(); got != 1 {
+						t.Errorf("key %v: got %v wins, want %v", d, got, 1)
+					}
+				}
+
+				want := util.GenerateIntSeries(501, 1500)
+				if got := ascend(m); !equal(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+
+				if got := m.Len(); got != len(want) {
+					t.Errorf("got %v, want %v", got, len(want))
+				}
+			})
+		}
+	})
+}
+
+func Test_Map_FloorCeiling(t *testing.T) {
+	type test struct {
+		name    string
+		key     int
+		floor   int
+		floorOK bool
+		ceil    int
+		ceilOK  bool
+	}
+
+	// the map holds 10, 20, ..., 100
+	tests := []test{
+		{name: "below first", key: 5, ceil: 10, ceilOK: true},
+		{name: "first", key: 10, floor: 10, floorOK: true, ceil: 10, ceilOK: true},
+		{name: "between", key: 55, floor: 50, floorOK: true, ceil: 60, ceilOK: true},
+		{name: "exact", key: 70, floor: 70, floorOK: true, ceil: 70, ceilOK: true},
+		{name: "last", key: 100, floor: 100, floorOK: true, ceil: 100, ceilOK: true},
+		{name: "above last", key: 105, floor: 100, floorOK: true},
+	}
+
+	for name, newMap := range implementations() {
+		for _, tt := range tests {
+			t.Run(name+" - "+tt.name, func(t *testing.T) {
+				m := newMap()
+				for _, d := range util.GenerateRandomIntSeries(1, 10) {
+					m.Put(d*10, d)
+				}
+
+				if got, v, ok := m.Floor(tt.key); got != tt.floor || ok != tt.floorOK || v != tt.floor/10 {
+					t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{tt.floor, tt.floorOK})
+				}
+
+				if got, v, ok := m.Ceiling(tt.key); got != tt.ceil || ok != tt.ceilOK || v != tt.ceil/10 {
+					t.Errorf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{tt.ceil, tt.ceilOK})
+				}
+			})
+		}
+	}
+}
+
+func Test_Map_Range(t *testing.T) {
+	type test struct {
+		name string
+		from int
+		to   int
+		want []int
+	}
+
+	// the map holds 1, 3, 5, ..., 99
+	tests := []test{
+		{name: "everything", from: 0, to: 100, want: odd(1, 99)},
+		{name: "inner", from: 10, to: 20, want: odd(11, 19)},
+		{name: "bounds present", from: 11, to: 19, want: odd(11, 17)},
+		{name: "empty", from: 40, to: 41, want: []int{}},
+		{name: "past the end", from: 95, to: 200, want: odd(95, 99)},
+	}
+
+	for name, newMap := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			for _, d := range odd(1, 99) {
+				m.Put(d, d)
+			}
+
+			if got, want := ascend(m), odd(1, 99); !equal(got, want) {
+				t.Errorf("ascend: got %v, want %v", got, want)
+			}
+
+			got := []int{}
+			m.Descend(func(key, _ int) bool {
+				got = append(got, key)
+				return true
+			})
+			if want := reversed(odd(1, 99)); !equal(got, want) {
+				t.Errorf("descend: got %v, want %v", got, want)
+			}
+
+			for _, tt := range tests {
+				got := []int{}
+				m.AscendRange(tt.from, tt.to, func(key, _ int) bool {
+					got = append(got, key)
+					return true
+				})
+				if !equal(got, tt.want) {
+					t.Errorf("ascend %v: got %v, want %v", tt.name, got, tt.want)
+				}
+
+				// (from, to] in reverse is the mirror image of [from, to)
+				// shifted by one
+				got = []int{}
+				m.DescendRange(tt.to-1, tt.from-1, func(key, _ int) bool {
+					got = append(got, key)
+					return true
+				})
+				if want := reversed(tt.want); !equal(got, want) {
+					t.Errorf("descend %v: got %v, want %v", tt.name, got, want)
+				}
+			}
+
+			// stopping early
+			got = []int{}
+			m.Ascend(func(key, _ int) bool {
+				got = append(got, key)
+				return len(got) < 3
+			})
+			if want := []int{1, 3, 5}; !equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_Map_Churn(t *testing.T) {
+	for name, newMap := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+
+			// even keys are never touched by the writers and must always
+			// be visible in order
+			for _, d := range util.GenerateIntSeries(0, 999) {
+				m.Put(2*d, 2*d)
+			}
+
+			var writers sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				writers.Add(1)
+				go func(w int) {
+					defer writers.Done()
+
+					for i := 0; i < 5000; i++ {
+						k := 2*((i*7+w*250)%1000) + 1
+						if i%2 == 0 {
+							m.Put(k, k)
+						} else {
+							m.Delete(k)
+						}
+					}
+				}(w)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				writers.Wait()
+				close(done)
+			}()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				got := ascend(m)
+				if !sort.IntsAreSorted(got) {
+					t.Fatalf("keys out of order: %v", got)
+				}
+
+				evens := 0
+				for i, k := range got {
+					if i > 0 && got[i-1] == k {
+						t.Fatalf("key %v visited twice", k)
+					}
+					if k%2 == 0 {
+						evens++
+					}
+				}
+
+				if evens != 1000 {
+					t.Fatalf("got %v stable keys, want %v", evens, 1000)
+				}
+
+				for _, k := range []int{0, 998, 1998} {
+					if got, ok := m.Get(k); got != k || !ok {
+						t.Fatalf("got %v, want %v", [2]interface{}{got, ok}, [2]interface{}{k, true})
+					}
+				}
+			}
+		})
+	}
+}
+
+func ascend(m skiplist.Map[int, int]) []int {
+	got := []int{}
+	m.Ascend(func(key, _ int) bool {
+		got = append(got, key)
+		return true
+	})
+
+	return got
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func odd(from, to int) []int {
+	var res []int
+	for i := from; i <= to; i += 2 {
+		res = append(res, i)
+	}
+
+	return res
+}
+
+func reversed(a []int) []int {
+	res := make([]int, 0, len(a))
+	for i := len(a) - 1; i >= 0; i-- {
+		res = append(res, a[i])
+	}
+
+	return res
+}
+
+func Test_Map_Linearizable(t *testing.T) {
+	const goroutines, ops, keys = 4, 250, 8
+
+	type input = lincheck.SetInput[int]
+
+	for name, newMap := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			r := lincheck.NewRecorder[input, bool]()
+
+			// the keys of the map are checked against the model of a set
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+
+					rng := util.NewRand(int64(g))
+					for i := 0; i < ops; i++ {
+						key := rng.Intn(keys)
+
+						switch rng.Intn(3) {
+						case 0:
+							r.Record(g, input{Op: lincheck.Add, Key: key}, func() bool {
+								_, replaced := m.Put(key, key)
+								return !replaced
+							})
+						case 1:
+							r.Record(g, input{Op: lincheck.Remove, Key: key}, func() bool {
+								_, ok := m.Delete(key)
+								return ok
+							})
+						default:
+							r.Record(g, input{Op: lincheck.Contains, Key: key}, func() bool {
+								return m.Contains(key)
+							})
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			if !lincheck.Check(lincheck.SetModel[int](), r.History()) {
+				t.Errorf("history is not linearizable")
+			}
+		})
+	}
+}